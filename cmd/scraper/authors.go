@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// runAuthorsCommand records the manual corrections of the author disambiguation
+func runAuthorsCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatal("missing authors sub-command (merge, split or replay)")
+	}
+
+	switch subCommand := args[0]; subCommand {
+	case "merge":
+		idList := parseIds(args[1:])
+		if len(idList) < 2 {
+			log.Fatal("usage: authors merge <target_author_id> <source_author_id>...")
+		}
+		err := database.MergeAuthors(idList[0], idList[1:], "manual merge")
+		if err != nil {
+			log.Fatalf("merging the authors: %s", err)
+		}
+		log.Infof("successfully merged %d authors into author %d", len(idList)-1, idList[0])
+	case "split":
+		idList := parseIds(args[1:])
+		if len(idList) < 2 {
+			log.Fatal("usage: authors split <author_id> <mention_id>...")
+		}
+		newAuthorId, err := database.SplitAuthor(idList[0], idList[1:], "manual split")
+		if err != nil {
+			log.Fatalf("splitting the author: %s", err)
+		}
+		log.Infof("successfully split %d mentions of author %d into author %d", len(idList)-1, idList[0], newAuthorId)
+	case "replay":
+		appliedCount, err := database.ReplayAuthorIdentityEvents()
+		if err != nil {
+			log.Fatalf("replaying the author identity events: %s", err)
+		}
+		log.Infof("successfully replayed the author identity events (%d applied)", appliedCount)
+	default:
+		log.Fatalf("unknown authors sub-command: %s", subCommand)
+	}
+}
+
+func parseIds(args []string) []database.ID {
+	var idList []database.ID
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			log.Fatalf("invalid id `%s`: %s", arg, err)
+		}
+		idList = append(idList, database.ID(id))
+	}
+	return idList
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/dedup"
+	log "github.com/sirupsen/logrus"
+)
+
+// runDedupCommand detects the duplicate papers, or handles the review queue of the uncertain ones
+func runDedupCommand(args []string) {
+	if len(args) == 0 {
+		err := dedup.DeduplicatePapers()
+		if err != nil {
+			log.Fatalf("deduplicating the papers: %s", err)
+		}
+		return
+	}
+
+	switch subCommand := args[0]; subCommand {
+	case "review":
+		err := dedup.LogReviewQueue()
+		if err != nil {
+			log.Fatalf("listing the duplicate candidates: %s", err)
+		}
+	case "accept", "reject":
+		idList := parseIds(args[1:])
+		if len(idList) == 0 {
+			log.Fatalf("usage: dedup %s <candidate_id>...", subCommand)
+		}
+		for _, candidateId := range idList {
+			err := database.ReviewDuplicateCandidate(candidateId, subCommand == "accept")
+			if err != nil {
+				log.Fatalf("reviewing the duplicate candidate %d: %s", candidateId, err)
+			}
+		}
+		log.Infof("successfully reviewed %d duplicate candidates", len(idList))
+	default:
+		log.Fatalf("unknown dedup sub-command: %s", subCommand)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// runFetchCommand fetches the given ids (or the ones listed in a file, or queued in the fetch jobs table)
+func runFetchCommand(websiteList []*database.Website, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: fetch <id>... | fetch file <path> | fetch queue <id>... | fetch jobs")
+	}
+
+	switch subCommand := args[0]; subCommand {
+	case "file":
+		if len(args) != 2 {
+			log.Fatal("usage: fetch file <path>")
+		}
+		idList, err := readIds(args[1])
+		if err != nil {
+			log.Fatalf("reading the ids: %s", err)
+		}
+		scraper.FetchWebsitesIds(websiteList, idList)
+	case "queue":
+		if len(args) < 2 {
+			log.Fatal("usage: fetch queue <id>...")
+		}
+		err := database.AddArxivFetchJobs(args[1:])
+		if err != nil {
+			log.Fatalf("queuing the ids: %s", err)
+		}
+		log.Infof("successfully queued %d ids", len(args)-1)
+	case "jobs":
+		scraper.FetchWebsitesQueuedIds(websiteList)
+	default:
+		scraper.FetchWebsitesIds(websiteList, args)
+	}
+}
+
+// readIds reads the ids of a file: separated by whitespaces or commas, ignoring the `#` comments
+func readIds(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var idList []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if commentIndex := strings.Index(line, "#"); commentIndex >= 0 {
+			line = line[:commentIndex]
+		}
+		idList = append(idList, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})...)
+	}
+
+	return idList, scanner.Err()
+}
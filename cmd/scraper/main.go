@@ -1,8 +1,12 @@
 package main
 
 import (
+	"github.com/papetier/scraper/pkg/citation"
+	"github.com/papetier/scraper/pkg/classification"
 	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/fulltext"
+	"github.com/papetier/scraper/pkg/papertext"
 	"github.com/papetier/scraper/pkg/scraper"
 	log "github.com/sirupsen/logrus"
 )
@@ -14,13 +18,63 @@ func main() {
 	database.Connect()
 	defer database.CloseConnection()
 
-	// setup scrapers
-	scraper.Setup()
-
 	websiteList, err := database.GetWebsites()
 	if err != nil {
 		log.Fatalf("an error occurred fetching the website list: %v", err)
 	}
 
-	scraper.ScrapeWebsites(websiteList)
+	switch command := config.GetCommand(); command {
+	case "", "scrape":
+		scraper.ScrapeWebsites(websiteList)
+	case "refresh":
+		scraper.RefreshWebsites(websiteList)
+	case "reparse":
+		scraper.ReparseWebsites(websiteList)
+	case "fetch":
+		runFetchCommand(websiteList, config.GetCommandArgs())
+	case "download":
+		scraper.DownloadWebsitesDocuments(websiteList)
+	case "extract":
+		err = fulltext.ExtractDocuments()
+		if err != nil {
+			log.Fatalf("extracting the documents' text: %s", err)
+		}
+	case "citations":
+		err = citation.ExtractCitations()
+		if err != nil {
+			log.Fatalf("extracting the citations: %s", err)
+		}
+	case "classifications":
+		err = database.SaveClassificationCodes(classification.Bundled())
+		if err != nil {
+			log.Fatalf("loading the bundled classification schemes: %s", err)
+		}
+	case "normalise":
+		err = papertext.NormalisePapers()
+		if err != nil {
+			log.Fatalf("normalising the papers' title and abstract: %s", err)
+		}
+	case "authors":
+		runAuthorsCommand(config.GetCommandArgs())
+	case "ror":
+		runRorCommand(config.GetCommandArgs())
+	case "dedup":
+		runDedupCommand(config.GetCommandArgs())
+	case "cleanup":
+		scraper.CleanupWebsites(websiteList)
+	case "migrate":
+		migratedCount, err := database.MigrateVersionedArxivIds()
+		if err != nil {
+			log.Fatalf("migrating the versioned arXiv ids: %s", err)
+		}
+		log.Infof("%d versioned arXiv ids migrated to their base id", migratedCount)
+
+		backfilledCount, err := database.BackfillPaperIdentifiers()
+		if err != nil {
+			log.Fatalf("backfilling the paper identifiers: %s", err)
+		}
+		log.Infof("%d papers backfilled with their identifiers", backfilledCount)
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
 }
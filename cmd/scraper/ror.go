@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/papetier/scraper/pkg/ror"
+	log "github.com/sirupsen/logrus"
+)
+
+// runRorCommand loads a ROR data dump or matches the organisations against it
+func runRorCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatal("missing ror sub-command (load or match)")
+	}
+
+	switch subCommand := args[0]; subCommand {
+	case "load":
+		if len(args) != 2 {
+			log.Fatal("usage: ror load <dump_path>")
+		}
+		err := ror.LoadDump(args[1])
+		if err != nil {
+			log.Fatalf("loading the ROR dump: %s", err)
+		}
+	case "match":
+		err := ror.MatchOrganisations()
+		if err != nil {
+			log.Fatalf("matching the organisations: %s", err)
+		}
+	default:
+		log.Fatalf("unknown ror sub-command: %s", subCommand)
+	}
+}
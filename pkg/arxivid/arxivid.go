@@ -0,0 +1,121 @@
+package arxivid
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	baseUrl = "https://arxiv.org/"
+
+	// new scheme (since 2007-04): YYMM.NNNN (until 2014-12) or YYMM.NNNNN, e.g. `1706.03762v5`
+	newIdPattern = `(\d{4}\.\d{4,5})`
+	// old scheme: archive(.SUBJECT-CLASS)/YYMMNNN, e.g. `solv-int/9901001v1`, `math.GT/0309136`
+	oldIdPattern   = `([a-zA-Z]+(?:-[a-zA-Z]+)*)(?:\.([A-Za-z]{2}))?/(\d{7})`
+	versionPattern = `(?:v(\d+))?`
+
+	// the URL forms: http(s), optional subdomain (e.g. `export.`), `/abs/` or `/pdf/` (optionally ending with `.pdf`)
+	urlPrefixPattern = `(?i)^(?:https?://)?(?:[a-z]+\.)?arxiv\.org/(?:abs|pdf)/`
+	labelPattern     = `(?i)^arxiv:\s*`
+)
+
+var idRegexp = regexp.MustCompile(`^(?:` + newIdPattern + `|` + oldIdPattern + `)` + versionPattern + `$`)
+var findRegexp = regexp.MustCompile(`(?i)(?:arXiv:\s*|arxiv\.org/(?:abs|pdf)/)(?:` + newIdPattern + `|` + oldIdPattern + `)` + versionPattern + `\b`)
+var urlPrefixRegexp = regexp.MustCompile(urlPrefixPattern)
+var labelRegexp = regexp.MustCompile(labelPattern)
+
+// ID is an arXiv identifier, in the new (`1706.03762`) or old (`cs/9308101`) scheme, with its version (0 if none)
+type ID struct {
+	Base    string
+	Version int
+}
+
+// Parse parses an arXiv identifier from a bare id, an `arXiv:` label or an arXiv URL
+// (e.g. `1706.03762v5`, `arXiv:cs/9308101`, `http://export.arxiv.org/abs/solv-int/9901001v1`, `https://arxiv.org/pdf/1706.03762v5.pdf`)
+// The old-scheme subject class is dropped (e.g. `math.GT/0309136` → `math/0309136`)
+func Parse(text string) (*ID, error) {
+	value := strings.TrimSpace(text)
+	value = labelRegexp.ReplaceAllString(value, "")
+	if urlPrefixRegexp.MatchString(value) {
+		value = urlPrefixRegexp.ReplaceAllString(value, "")
+		if queryIndex := strings.IndexAny(value, "?#"); queryIndex >= 0 {
+			value = value[:queryIndex]
+		}
+		value = strings.TrimSuffix(strings.TrimSuffix(value, "/"), ".pdf")
+	}
+
+	result := idRegexp.FindStringSubmatch(value)
+	if result == nil {
+		return nil, fmt.Errorf("invalid arXiv identifier `%s`", text)
+	}
+	return fromSubmatch(result)
+}
+
+// Find returns the arXiv identifiers referenced in a text (i.e. with an `arXiv:` label or as arXiv URL)
+func Find(text string) []*ID {
+	var idList []*ID
+	for _, result := range findRegexp.FindAllStringSubmatch(text, -1) {
+		if id, err := fromSubmatch(result); err == nil {
+			idList = append(idList, id)
+		}
+	}
+	return idList
+}
+
+// RemoveAll removes the arXiv identifiers referenced in a text (e.g. to parse the rest without their digits)
+func RemoveAll(text string) string {
+	return findRegexp.ReplaceAllString(text, "")
+}
+
+// fromSubmatch builds the id from the submatches: new id, old archive, old subject class, old number, version
+func fromSubmatch(result []string) (*ID, error) {
+	id := &ID{}
+	if result[1] != "" {
+		id.Base = result[1]
+	} else {
+		id.Base = strings.ToLower(result[2]) + "/" + result[4]
+	}
+
+	if result[5] != "" {
+		version, err := strconv.Atoi(result[5])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid arXiv identifier version `%s`", result[5])
+		}
+		id.Version = version
+	}
+
+	return id, nil
+}
+
+// String returns the identifier, with its version if any (e.g. `1706.03762v5`)
+func (id *ID) String() string {
+	if id.Version == 0 {
+		return id.Base
+	}
+	return id.Base + "v" + strconv.Itoa(id.Version)
+}
+
+// IsLegacy tells whether the identifier follows the old scheme (before 2007-04)
+func (id *ID) IsLegacy() bool {
+	return strings.Contains(id.Base, "/")
+}
+
+// WithVersion returns the identifier of the given version (0 for the unversioned one)
+func (id *ID) WithVersion(version int) *ID {
+	return &ID{
+		Base:    id.Base,
+		Version: version,
+	}
+}
+
+// AbsUrl returns the canonical URL of the abstract page
+func (id *ID) AbsUrl() string {
+	return baseUrl + "abs/" + id.String()
+}
+
+// PdfUrl returns the canonical URL of the PDF
+func (id *ID) PdfUrl() string {
+	return baseUrl + "pdf/" + id.String()
+}
@@ -0,0 +1,33 @@
+package blob
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/config"
+)
+
+const (
+	FilesystemStoreType = "filesystem"
+	S3StoreType         = "s3"
+)
+
+// Store is a key-based storage for binary objects (e.g. PDFs)
+type Store interface {
+	// Put writes the content under the key, overwriting any existing object
+	Put(key string, content []byte, contentType string) error
+	// Get reads the content stored under the key
+	Get(key string) ([]byte, error)
+	// Exists returns true if an object is stored under the key
+	Exists(key string) (bool, error)
+}
+
+// NewStore returns the blob store defined in the config
+func NewStore() (Store, error) {
+	switch config.Blob.StoreType {
+	case FilesystemStoreType:
+		return NewFilesystemStore(config.Blob.Path), nil
+	case S3StoreType:
+		return NewS3Store(config.Blob.S3Endpoint, config.Blob.S3Region, config.Blob.S3Bucket, config.Blob.S3AccessKeyId, config.Blob.S3SecretAccessKey), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store type: %s", config.Blob.StoreType)
+	}
+}
@@ -0,0 +1,74 @@
+package blob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FilesystemStore keeps the objects as files under a root directory
+type FilesystemStore struct {
+	Root string
+}
+
+func NewFilesystemStore(root string) *FilesystemStore {
+	return &FilesystemStore{
+		Root: root,
+	}
+}
+
+func (f *FilesystemStore) Put(key string, content []byte, _ string) error {
+	objectPath := f.path(key)
+	err := os.MkdirAll(filepath.Dir(objectPath), 0755)
+	if err != nil {
+		return fmt.Errorf("creating the directory for %s: %w", key, err)
+	}
+
+	// write to a temporary file first so that an object is never partially written
+	tmpFile, err := ioutil.TempFile(filepath.Dir(objectPath), filepath.Base(objectPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating the temporary file for %s: %w", key, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(content)
+	if err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("writing %s: %w", key, err)
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("closing the temporary file for %s: %w", key, err)
+	}
+
+	err = os.Rename(tmpFile.Name(), objectPath)
+	if err != nil {
+		return fmt.Errorf("moving %s into the store: %w", key, err)
+	}
+
+	return nil
+}
+
+func (f *FilesystemStore) Get(key string) ([]byte, error) {
+	content, err := ioutil.ReadFile(f.path(key))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", key, err)
+	}
+	return content, nil
+}
+
+func (f *FilesystemStore) Exists(key string) (bool, error) {
+	_, err := os.Stat(f.path(key))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("checking %s: %w", key, err)
+}
+
+func (f *FilesystemStore) path(key string) string {
+	return filepath.Join(f.Root, filepath.FromSlash(key))
+}
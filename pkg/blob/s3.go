@@ -0,0 +1,207 @@
+package blob
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service          = "s3"
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	s3DateFormat       = "20060102"
+	s3DateTimeFormat   = "20060102T150405Z"
+)
+
+// S3Store keeps the objects in an S3-compatible bucket (e.g. AWS S3, MinIO)
+// Requests use path-style URLs (i.e. `<endpoint>/<bucket>/<key>`) signed with AWS Signature Version 4
+type S3Store struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyId     string
+	SecretAccessKey string
+
+	client *http.Client
+}
+
+func NewS3Store(endpoint, region, bucket, accessKeyId, secretAccessKey string) *S3Store {
+	return &S3Store{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyId:     accessKeyId,
+		SecretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (s *S3Store) Put(key string, content []byte, contentType string) error {
+	req, err := s.newSignedRequest(http.MethodPut, key, content, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return s.responseError("uploading", key, res)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(key string) ([]byte, error) {
+	req, err := s.newSignedRequest(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", key, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, s.responseError("downloading", key, res)
+	}
+
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", key, err)
+	}
+
+	return content, nil
+}
+
+func (s *S3Store) Exists(key string) (bool, error) {
+	req, err := s.newSignedRequest(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("checking %s: %w", key, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, s.responseError("checking", key, res)
+	}
+}
+
+func (s *S3Store) newSignedRequest(method, key string, payload []byte, headers map[string]string) (*http.Request, error) {
+	canonicalUri := "/" + uriEncode(s.Bucket, false) + "/" + uriEncode(key, true)
+	req, err := http.NewRequest(method, s.Endpoint+canonicalUri, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("preparing the %s request for %s: %w", method, key, err)
+	}
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	now := time.Now().UTC()
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", now.Format(s3DateTimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// canonical headers (host + x-amz-* + content-type)
+	signedHeaderValues := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name := range req.Header {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, "x-amz-") || lowerName == "content-type" {
+			signedHeaderValues[lowerName] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	var signedHeaderNames []string
+	for name := range signedHeaderValues {
+		signedHeaderNames = append(signedHeaderNames, name)
+	}
+	sort.Strings(signedHeaderNames)
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaderNames {
+		canonicalHeaders.WriteString(name + ":" + signedHeaderValues[name] + "\n")
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalUri,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format(s3DateFormat) + "/" + s.Region + "/" + s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3SigningAlgorithm,
+		now.Format(s3DateTimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+s.SecretAccessKey), now.Format(s3DateFormat))
+	signingKey = hmacSha256(signingKey, s.Region)
+	signingKey = hmacSha256(signingKey, s3Service)
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", s3SigningAlgorithm+" Credential="+s.AccessKeyId+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+	return req, nil
+}
+
+func (s *S3Store) responseError(action, key string, res *http.Response) error {
+	body, _ := ioutil.ReadAll(res.Body)
+	return fmt.Errorf("%s %s failed with HTTP status %d: %s", action, key, res.StatusCode, string(body))
+}
+
+// uriEncode encodes every byte except the unreserved characters, as expected by AWS Signature Version 4
+func uriEncode(value string, isSlashKept bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && isSlashKept:
+			encoded.WriteByte(b)
+		default:
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return encoded.String()
+}
+
+func sha256Hex(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,163 @@
+package citation
+
+import (
+	"github.com/papetier/scraper/pkg/arxivid"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/doi"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	doiPattern             = `(?i)\b(10\.\d{4,9}/[^\s"<>]+)`
+	yearInBracketsPattern  = `\((\d{4})[a-z]?\)`
+	yearPattern            = `\b((?:19|20)\d{2})[a-z]?\b`
+	quotedTitlePattern     = `[“"]([^”"]{10,})[”"]`
+	authorSeparatorPattern = `\s*(?:,\s*and\s+|\s+and\s+|\s*&\s*|;\s*|,\s*)`
+	initialsPattern        = `^(?:[A-Z]\.\s*-?)+$|^[A-Z]{1,3}$`
+	surnameFirstPattern    = `^[\p{Lu}][\p{L}'’\-]+,\s+[A-Z]\.`
+	authorContinuePattern  = `^(?:[A-Z]\.|and\b|&|[\p{Lu}][\p{L}'’\-]+,)`
+	minYear                = 1800
+	maxYear                = 2100
+)
+
+var doiRegexp = regexp.MustCompile(doiPattern)
+var yearInBracketsRegexp = regexp.MustCompile(yearInBracketsPattern)
+var yearRegexp = regexp.MustCompile(yearPattern)
+var quotedTitleRegexp = regexp.MustCompile(quotedTitlePattern)
+var authorSeparatorRegexp = regexp.MustCompile(authorSeparatorPattern)
+var initialsRegexp = regexp.MustCompile(initialsPattern)
+var surnameFirstRegexp = regexp.MustCompile(surnameFirstPattern)
+var authorContinueRegexp = regexp.MustCompile(authorContinuePattern)
+
+// ParseReference extracts the structured fields of a raw reference (best effort)
+func ParseReference(rawReference string) *database.Citation {
+	citation := &database.Citation{
+		RawText: rawReference,
+	}
+
+	// identifiers
+	if arxivIdList := arxivid.Find(rawReference); len(arxivIdList) > 0 {
+		arxivId := arxivIdList[0].String()
+		citation.ArxivId = &arxivId
+	}
+	if doiList := doi.Parse(rawReference); len(doiList) > 0 {
+		citation.Doi = &doiList[0]
+	}
+
+	// year: in brackets (author-year style) or the last plausible one
+	year := parseYear(rawReference)
+	if year != nil {
+		citation.Year = year
+	}
+
+	// authors + title
+	authorsPart, title := splitAuthorsAndTitle(rawReference)
+	citation.Authors = splitAuthors(authorsPart)
+	if title != "" {
+		citation.Title = &title
+	}
+
+	return citation
+}
+
+func parseYear(rawReference string) *int {
+	if result := yearInBracketsRegexp.FindStringSubmatch(rawReference); len(result) > 1 {
+		if year, err := strconv.Atoi(result[1]); err == nil && year >= minYear && year <= maxYear {
+			return &year
+		}
+	}
+
+	// ignore the years inside identifiers (e.g. arXiv ids, DOIs)
+	cleanedReference := arxivid.RemoveAll(rawReference)
+	cleanedReference = doiRegexp.ReplaceAllString(cleanedReference, "")
+	resultList := yearRegexp.FindAllStringSubmatch(cleanedReference, -1)
+	if len(resultList) == 0 {
+		return nil
+	}
+	year, err := strconv.Atoi(resultList[len(resultList)-1][1])
+	if err != nil {
+		return nil
+	}
+	return &year
+}
+
+// splitAuthorsAndTitle returns the author list part and the title of a reference
+// The title is either quoted, follows the year in brackets, or is the sentence after the authors
+func splitAuthorsAndTitle(rawReference string) (string, string) {
+	if result := quotedTitleRegexp.FindStringSubmatchIndex(rawReference); result != nil {
+		title := strings.TrimRight(strings.TrimSpace(rawReference[result[2]:result[3]]), ",.")
+		return strings.TrimRight(strings.TrimSpace(rawReference[:result[0]]), ",."), title
+	}
+
+	if result := yearInBracketsRegexp.FindStringIndex(rawReference); result != nil {
+		authorsPart := strings.TrimRight(strings.TrimSpace(rawReference[:result[0]]), ",.")
+		sentenceList := splitSentences(rawReference[result[1]:])
+		if len(sentenceList) > 0 {
+			return authorsPart, sentenceList[0]
+		}
+		return authorsPart, ""
+	}
+
+	sentenceList := splitSentences(rawReference)
+	switch len(sentenceList) {
+	case 0:
+		return "", ""
+	case 1:
+		return sentenceList[0], ""
+	default:
+		return sentenceList[0], sentenceList[1]
+	}
+}
+
+// splitSentences splits on periods, ignoring the ones ending an initial (e.g. `J. Smith`)
+// With surname-first author lists (e.g. `Smith, J., Doe, A. Title`), an initial ends the sentence unless followed by another author
+func splitSentences(text string) []string {
+	isSurnameFirst := surnameFirstRegexp.MatchString(text)
+
+	var sentenceList []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '.' || (i+1 < len(text) && text[i+1] != ' ') {
+			continue
+		}
+		// initial: single uppercase letter before the period
+		if i >= 1 && text[i-1] >= 'A' && text[i-1] <= 'Z' && (i == 1 || text[i-2] == ' ' || text[i-2] == '.' || text[i-2] == '-') {
+			if !isSurnameFirst || authorContinueRegexp.MatchString(strings.TrimSpace(text[i+1:])) {
+				continue
+			}
+		}
+		sentence := strings.TrimSpace(text[start:i])
+		if sentence != "" {
+			sentenceList = append(sentenceList, sentence)
+		}
+		start = i + 1
+	}
+	if sentence := strings.TrimSpace(strings.TrimRight(text[start:], ".")); sentence != "" {
+		sentenceList = append(sentenceList, sentence)
+	}
+	return sentenceList
+}
+
+// splitAuthors splits an author list, merging back the `Surname, I.` pairs
+func splitAuthors(authorsPart string) []string {
+	if authorsPart == "" {
+		return nil
+	}
+
+	var authorList []string
+	for _, part := range authorSeparatorRegexp.Split(authorsPart, -1) {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), "and "))
+		if part == "" || strings.EqualFold(part, "et al.") || strings.EqualFold(part, "et al") {
+			continue
+		}
+		if initialsRegexp.MatchString(part) && len(authorList) > 0 {
+			authorList[len(authorList)-1] = authorList[len(authorList)-1] + ", " + part
+			continue
+		}
+		authorList = append(authorList, part)
+	}
+
+	return authorList
+}
@@ -0,0 +1,134 @@
+package citation
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	referencesHeadingPattern = `(?im)^\s*(?:[0-9]+\.?|[IVX]+\.)?\s*(references|bibliography|literature cited|works cited)\s*$`
+	sectionEndPattern        = `(?im)^\s*(?:[A-Z0-9]+\.?\s+)?(appendix|appendices|supplementary material)\b.*$`
+	numberedMarkerPattern    = `(?m)^\s*(?:\[(\d{1,3})\]|(\d{1,3})\.\s)`
+	authorStartPattern       = `^[A-Z][\p{L}'’\-]+,?\s+(?:[A-Z]\.|[A-Z][\p{L}'’\-]+)`
+)
+
+var referencesHeadingRegexp = regexp.MustCompile(referencesHeadingPattern)
+var sectionEndRegexp = regexp.MustCompile(sectionEndPattern)
+var numberedMarkerRegexp = regexp.MustCompile(numberedMarkerPattern)
+var authorStartRegexp = regexp.MustCompile(authorStartPattern)
+
+// FindReferencesSection returns the text following the last references heading (up to an appendix, if any)
+func FindReferencesSection(text string) string {
+	headingIndexList := referencesHeadingRegexp.FindAllStringIndex(text, -1)
+	if len(headingIndexList) == 0 {
+		return ""
+	}
+	section := text[headingIndexList[len(headingIndexList)-1][1]:]
+
+	if endIndex := sectionEndRegexp.FindStringIndex(section); endIndex != nil {
+		section = section[:endIndex[0]]
+	}
+
+	return strings.TrimSpace(section)
+}
+
+// SplitReferences splits a references section into raw references
+// Numbered references (i.e. `[1]` or `1.`) are split on their markers, others on lines starting like an author list
+func SplitReferences(section string) []string {
+	if section == "" {
+		return nil
+	}
+
+	var referenceList []string
+	markerIndexList := numberedMarkerRegexp.FindAllStringSubmatchIndex(section, -1)
+	if len(markerIndexList) >= 2 && isSequentiallyNumbered(section, markerIndexList) {
+		for i, markerIndex := range markerIndexList {
+			end := len(section)
+			if i+1 < len(markerIndexList) {
+				end = markerIndexList[i+1][0]
+			}
+			referenceList = appendReference(referenceList, section[markerIndex[1]:end])
+		}
+		return referenceList
+	}
+
+	// author-year style: a reference starts on a line looking like an author list, after a line ending a sentence
+	var current []string
+	previousLine := ""
+	for _, line := range strings.Split(section, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		isNewReference := authorStartRegexp.MatchString(line) && (previousLine == "" || strings.HasSuffix(previousLine, "."))
+		if isNewReference && len(current) > 0 {
+			referenceList = appendReference(referenceList, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+		previousLine = line
+	}
+	if len(current) > 0 {
+		referenceList = appendReference(referenceList, strings.Join(current, "\n"))
+	}
+
+	return referenceList
+}
+
+// isSequentiallyNumbered checks that the markers mostly follow each other (i.e. aren't random numbers at line starts)
+func isSequentiallyNumbered(section string, markerIndexList [][]int) bool {
+	sequentialCount := 0
+	previousNumber := 0
+	for _, markerIndex := range markerIndexList {
+		number := markerNumber(section, markerIndex)
+		if number == previousNumber+1 {
+			sequentialCount++
+		}
+		previousNumber = number
+	}
+	return sequentialCount*2 >= len(markerIndexList)
+}
+
+func markerNumber(section string, markerIndex []int) int {
+	var digits string
+	if markerIndex[2] >= 0 {
+		digits = section[markerIndex[2]:markerIndex[3]]
+	} else {
+		digits = section[markerIndex[4]:markerIndex[5]]
+	}
+	number := 0
+	for _, digit := range digits {
+		number = number*10 + int(digit-'0')
+	}
+	return number
+}
+
+func appendReference(referenceList []string, rawReference string) []string {
+	reference := joinLines(rawReference)
+	if len(reference) < 10 {
+		return referenceList
+	}
+	return append(referenceList, reference)
+}
+
+// joinLines merges a reference's lines, removing the hyphenation at line ends
+func joinLines(text string) string {
+	var builder strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		current := builder.String()
+		switch {
+		case current == "":
+		case strings.HasSuffix(current, "-") && len(line) > 0 && line[0] >= 'a' && line[0] <= 'z':
+			builder.Reset()
+			builder.WriteString(strings.TrimSuffix(current, "-"))
+		default:
+			builder.WriteString(" ")
+		}
+		builder.WriteString(line)
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
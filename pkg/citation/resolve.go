@@ -0,0 +1,47 @@
+package citation
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/papertext"
+)
+
+// findCitedPaperId matches a citation against the existing papers: by arXiv id, DOI, then title (nil if unresolved)
+// The titles are compared by their search version (i.e. case, accent, punctuation and LaTeX-insensitive)
+func findCitedPaperId(citation *database.Citation) (*database.ID, error) {
+	if citation.ArxivId != nil {
+		paperId, err := database.FindPaperIdByArxivId(*citation.ArxivId)
+		if err != nil {
+			return nil, fmt.Errorf("matching the arXiv id %s: %w", *citation.ArxivId, err)
+		}
+		if paperId != nil {
+			return paperId, nil
+		}
+	}
+
+	if citation.Doi != nil {
+		paperId, err := database.FindPaperIdByDoi(*citation.Doi)
+		if err != nil {
+			return nil, fmt.Errorf("matching the DOI %s: %w", *citation.Doi, err)
+		}
+		if paperId != nil {
+			return paperId, nil
+		}
+	}
+
+	if citation.Title != nil && len(*citation.Title) >= minMatchedTitleLength {
+		searchTitle := papertext.Search(*citation.Title)
+		if searchTitle == "" {
+			return nil, nil
+		}
+		paperId, err := database.FindPaperIdBySearchTitle(searchTitle)
+		if err != nil {
+			return nil, fmt.Errorf("matching the title %s: %w", *citation.Title, err)
+		}
+		if paperId != nil {
+			return paperId, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,106 @@
+package citation
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// titles shorter than this are too generic to be matched
+const minMatchedTitleLength = 20
+
+// ExtractCitations extracts the references of the extracted documents not processed yet, then retries to resolve
+// the previously unresolved citations (i.e. cited papers scraped since)
+func ExtractCitations() error {
+	err := extractNewCitations()
+	if err != nil {
+		return err
+	}
+
+	return resolveCitations()
+}
+
+func extractNewCitations() error {
+	citationCount := 0
+	resolvedCount := 0
+	for {
+		extractionList, err := database.GetExtractionsWithoutReferences(config.Extract.BatchSize)
+		if err != nil {
+			return fmt.Errorf("fetching the extractions to process: %w", err)
+		}
+		if len(extractionList) == 0 {
+			break
+		}
+
+		for _, extraction := range extractionList {
+			text, err := extraction.GetText()
+			if err != nil {
+				return fmt.Errorf("reading the text of document %d: %w", extraction.DocumentId, err)
+			}
+
+			var citationList []*database.Citation
+			for position, rawReference := range SplitReferences(FindReferencesSection(text)) {
+				citation := ParseReference(rawReference)
+				citation.Position = position
+				citation.CreatedAt = time.Now()
+
+				citedPaperId, err := findCitedPaperId(citation)
+				if err != nil {
+					return fmt.Errorf("resolving a citation of paper %d: %w", extraction.PaperId, err)
+				}
+				if citedPaperId != nil && *citedPaperId != extraction.PaperId {
+					citation.CitedPaperId = citedPaperId
+					citation.ResolvedAt = &citation.CreatedAt
+					resolvedCount++
+				}
+				citationList = append(citationList, citation)
+			}
+
+			err = database.SaveCitationsForExtraction(extraction, citationList)
+			if err != nil {
+				return fmt.Errorf("saving the citations of paper %d: %w", extraction.PaperId, err)
+			}
+			citationCount += len(citationList)
+		}
+	}
+
+	log.Infof("citation extraction done: %d citations extracted, %d resolved", citationCount, resolvedCount)
+	return nil
+}
+
+func resolveCitations() error {
+	resolvedCount := 0
+	var lastId database.ID
+	for {
+		citationList, err := database.GetUnresolvedCitations(lastId, config.Extract.BatchSize)
+		if err != nil {
+			return fmt.Errorf("fetching the unresolved citations: %w", err)
+		}
+		if len(citationList) == 0 {
+			break
+		}
+
+		for _, citation := range citationList {
+			lastId = citation.Id
+
+			citedPaperId, err := findCitedPaperId(citation)
+			if err != nil {
+				return fmt.Errorf("resolving the citation %d: %w", citation.Id, err)
+			}
+			if citedPaperId == nil || *citedPaperId == citation.CitingPaperId {
+				continue
+			}
+
+			err = citation.Resolve(*citedPaperId)
+			if err != nil {
+				return err
+			}
+			resolvedCount++
+		}
+	}
+
+	log.Infof("citation resolution done: %d previously unresolved citations resolved", resolvedCount)
+	return nil
+}
@@ -0,0 +1,156 @@
+package classification
+
+import (
+	_ "embed"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed msc2020.tsv
+var mscFile string
+
+//go:embed acm_ccs1998.tsv
+var acmFile string
+
+// the classification schemes
+const (
+	Msc2020    = "msc2020"
+	AcmCcs1998 = "acm_ccs1998"
+)
+
+const (
+	// e.g. `68T05`, `68Txx`, `68-XX`, `68-02`
+	mscPattern = `^(\d{2})(?:([A-Za-z])(\d{2}|xx)|-(XX|xx|\d{2}))$`
+	// e.g. `I`, `I.2`, `I.2.6`, `I.2.m`
+	acmPattern = `^[A-K](?:\.(?:\d{1,2}|m)){0,3}$`
+	// the codes may be listed in a single term, e.g. `68T05; 68T10`, `I.2.6, I.2.7` or `Primary 68T05 (Secondary 68T10)`
+	termSeparators    = ";,() \t"
+	annotationPattern = `(?i)\b(?:primary|secondary)\b:?`
+	// the punctuation ending a code in a sentence, e.g. `Primary: 14H55. Secondary: 14H30`
+	trailingPunctuation = ".:"
+)
+
+var mscRegexp = regexp.MustCompile(mscPattern)
+var acmRegexp = regexp.MustCompile(acmPattern)
+var annotationRegexp = regexp.MustCompile(annotationPattern)
+
+var bundledNameMapBySchemeAndCode map[string]map[string]string
+var loadBundledOnce sync.Once
+
+// Parse returns the classification codes of a term (e.g. an atom category term), nil if it has none (e.g. an arXiv
+// category like `cs.LG`); the codes have their parents (by structure) and bundled names
+// The words which aren't codes are skipped (e.g. `J.2 (Physical sciences)`), as well as the single letter ACM codes
+// within a longer term (e.g. `A` as a word)
+func Parse(term string) []*database.ClassificationCode {
+	var codeList []*database.ClassificationCode
+	codeSet := make(map[string]struct{})
+	term = annotationRegexp.ReplaceAllString(term, " ")
+	rawCodeList := strings.FieldsFunc(term, isTermSeparator)
+	for _, rawCode := range rawCodeList {
+		code := parseCode(strings.TrimRight(rawCode, trailingPunctuation))
+		if code == nil || (code.Parent == nil && code.Scheme == AcmCcs1998 && len(rawCodeList) > 1) {
+			continue
+		}
+		if _, exists := codeSet[code.Scheme+code.Code]; exists {
+			continue
+		}
+		codeList = append(codeList, code)
+		codeSet[code.Scheme+code.Code] = struct{}{}
+	}
+	return codeList
+}
+
+// Bundled returns the codes of the bundled scheme files
+func Bundled() []*database.ClassificationCode {
+	var codeList []*database.ClassificationCode
+	for _, scheme := range []string{Msc2020, AcmCcs1998} {
+		for code := range getBundledNameMap(scheme) {
+			codeList = append(codeList, newCode(scheme, code))
+		}
+	}
+	return codeList
+}
+
+func parseCode(rawCode string) *database.ClassificationCode {
+	if result := mscRegexp.FindStringSubmatch(rawCode); result != nil {
+		code := result[1] + "-" + strings.ToUpper(result[4])
+		if result[2] != "" {
+			code = result[1] + strings.ToUpper(result[2]) + strings.ToLower(result[3])
+		}
+		return newCode(Msc2020, code)
+	}
+	if acmRegexp.MatchString(rawCode) {
+		return newCode(AcmCcs1998, rawCode)
+	}
+	return nil
+}
+
+// newCode returns the code with its name (if bundled) and its ancestors
+func newCode(scheme string, code string) *database.ClassificationCode {
+	classificationCode := &database.ClassificationCode{
+		Scheme: scheme,
+		Code:   code,
+	}
+	if name, exists := getBundledNameMap(scheme)[code]; exists {
+		classificationCode.Name = &name
+		classificationCode.IsBundled = true
+	}
+	if parentCode := getParentCode(scheme, code); parentCode != "" {
+		classificationCode.ParentCode = &parentCode
+		classificationCode.Parent = newCode(scheme, parentCode)
+	}
+	return classificationCode
+}
+
+// getParentCode returns the code of the parent, by structure: `68T05` → `68Txx` → `68-XX`, `I.2.6` → `I.2` → `I`
+func getParentCode(scheme string, code string) string {
+	switch scheme {
+	case Msc2020:
+		if strings.HasSuffix(code, "-XX") {
+			return ""
+		}
+		if strings.HasSuffix(code, "xx") || code[2] == '-' {
+			return code[:2] + "-XX"
+		}
+		return code[:3] + "xx"
+	case AcmCcs1998:
+		if lastDotIndex := strings.LastIndex(code, "."); lastDotIndex >= 0 {
+			return code[:lastDotIndex]
+		}
+	}
+	return ""
+}
+
+func getBundledNameMap(scheme string) map[string]string {
+	loadBundledOnce.Do(func() {
+		bundledNameMapBySchemeAndCode = map[string]map[string]string{
+			Msc2020:    parseSchemeFile(Msc2020, mscFile),
+			AcmCcs1998: parseSchemeFile(AcmCcs1998, acmFile),
+		}
+	})
+	return bundledNameMapBySchemeAndCode[scheme]
+}
+
+// parseSchemeFile reads the `code<TAB>name` lines of a scheme file
+func parseSchemeFile(scheme string, content string) map[string]string {
+	nameMap := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fieldList := strings.Split(line, "\t")
+		if len(fieldList) != 2 {
+			log.Warnf("ignoring the malformed %s line `%s`", scheme, line)
+			continue
+		}
+		nameMap[fieldList[0]] = fieldList[1]
+	}
+	return nameMap
+}
+
+func isTermSeparator(r rune) bool {
+	return strings.ContainsRune(termSeparators, r)
+}
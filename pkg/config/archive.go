@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type ArchiveConfig struct {
+	Path string
+}
+
+var Archive *ArchiveConfig
+
+// IsEnabled returns true when a path is set to archive the raw responses
+func (a *ArchiveConfig) IsEnabled() bool {
+	return a.Path != ""
+}
+
+func loadArchiveConfig() {
+	Archive = &ArchiveConfig{
+		Path: viper.GetString("ARCHIVE_PATH"),
+	}
+}
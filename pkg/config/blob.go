@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type BlobConfig struct {
+	StoreType         string
+	Path              string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyId     string
+	S3SecretAccessKey string
+}
+
+var Blob *BlobConfig
+
+func loadBlobConfig() {
+	Blob = &BlobConfig{
+		StoreType:         viper.GetString("BLOB_STORE_TYPE"),
+		Path:              viper.GetString("BLOB_STORE_PATH"),
+		S3Endpoint:        viper.GetString("S3_ENDPOINT"),
+		S3Region:          viper.GetString("S3_REGION"),
+		S3Bucket:          viper.GetString("S3_BUCKET"),
+		S3AccessKeyId:     viper.GetString("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: viper.GetString("S3_SECRET_ACCESS_KEY"),
+	}
+}
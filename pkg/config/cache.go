@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+	"time"
+)
+
+type CacheConfig struct {
+	IsEnabled bool
+	TTL       time.Duration
+}
+
+var Cache *CacheConfig
+
+func loadCacheConfig() {
+	Cache = &CacheConfig{
+		IsEnabled: viper.GetBool("HTTP_CACHE_ENABLED"),
+		TTL:       viper.GetDuration("HTTP_CACHE_TTL"),
+	}
+}
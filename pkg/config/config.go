@@ -74,12 +74,40 @@ func load() {
 	// DB config
 	loadDbConfig()
 
+	// colly storage
+	loadStorageConfig()
+
+	// raw response archive
+	loadArchiveConfig()
+
+	// HTTP cache
+	loadCacheConfig()
+
+	// blob store
+	loadBlobConfig()
+
+	// full-text extraction
+	loadExtractConfig()
+
 	// scraper
 	loadScraperConfig()
 
 	log.Infof("%s config successfully loaded", environment)
 }
 
+// GetCommand returns the command passed as first positional argument (empty if none)
+func GetCommand() string {
+	return pflag.Arg(0)
+}
+
+// GetCommandArgs returns the positional arguments following the command
+func GetCommandArgs() []string {
+	if pflag.NArg() < 2 {
+		return nil
+	}
+	return pflag.Args()[1:]
+}
+
 func parseFlags() {
 	pflag.BoolP("version", "v", false, "prints the version")
 	pflag.Parse()
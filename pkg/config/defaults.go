@@ -16,12 +16,31 @@ func setDefaultConfigValues() {
 	viper.SetDefault("POSTGRES_PASSWORD", "postgres")
 	viper.SetDefault("POSTGRES_USER", "postgres")
 
+	// colly storage defaults
+	viper.SetDefault("STORAGE_VISITED_TTL", 0)
+
+	// HTTP cache defaults
+	viper.SetDefault("HTTP_CACHE_ENABLED", false)
+	viper.SetDefault("HTTP_CACHE_TTL", time.Hour)
+
+	// blob store defaults
+	viper.SetDefault("BLOB_STORE_TYPE", "filesystem")
+	viper.SetDefault("BLOB_STORE_PATH", "blobs")
+	viper.SetDefault("S3_REGION", "us-east-1")
+
+	// full-text extraction defaults
+	viper.SetDefault("EXTRACT_BATCH_SIZE", 50)
+
 	// arXiv scraper defaults
 	viper.SetDefault("ARXIV_ACCEPT_INSECURE_HTTP", false)
 	viper.SetDefault("ARXIV_REQUEST_TIMEOUT", 30*time.Second)
 	viper.SetDefault("ARXIV_DUPLICATED_THRESHOLD", 3)
+	viper.SetDefault("ARXIV_ID_LIST_BATCH_SIZE", 100)
 	viper.SetDefault("ARXIV_MAX_RESULTS", 1000)
 	viper.SetDefault("ARXIV_SEARCH_START", 0)
 	viper.SetDefault("ARXIV_SORT_BY", "submittedDate")
 	viper.SetDefault("ARXIV_SORT_ORDER", "ascending")
+	viper.SetDefault("ARXIV_PDF_DOWNLOAD_LIMIT", 100)
+	viper.SetDefault("ARXIV_REFRESH_LOOKBACK", 48*time.Hour)
+	viper.SetDefault("ARXIV_TAXONOMY_MAX_AGE", 24*time.Hour)
 }
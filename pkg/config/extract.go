@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type ExtractConfig struct {
+	BatchSize int
+}
+
+var Extract *ExtractConfig
+
+func loadExtractConfig() {
+	Extract = &ExtractConfig{
+		BatchSize: viper.GetInt("EXTRACT_BATCH_SIZE"),
+	}
+}
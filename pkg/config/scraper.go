@@ -12,10 +12,14 @@ type ArxivConfig struct {
 	IsInsecureHttpAccepted bool
 	RequestTimeout         time.Duration
 	DuplicatedThreshold    int
+	IdListBatchSize        int
 	MaxResults             int
+	PdfDownloadLimit       int
+	RefreshLookback        time.Duration
 	SearchStart            int
 	SortBy                 string
 	SortOrder              string
+	TaxonomyMaxAge         time.Duration
 }
 
 var Arxiv *ArxivConfig
@@ -46,9 +50,13 @@ func loadScraperConfig() {
 		IsInsecureHttpAccepted: viper.GetBool("ARXIV_ACCEPT_INSECURE_HTTP"),
 		RequestTimeout:         viper.GetDuration("ARXIV_REQUEST_TIMEOUT"),
 		DuplicatedThreshold:    viper.GetInt("ARXIV_DUPLICATED_THRESHOLD"),
+		IdListBatchSize:        viper.GetInt("ARXIV_ID_LIST_BATCH_SIZE"),
 		MaxResults:             viper.GetInt("ARXIV_MAX_RESULTS"),
+		PdfDownloadLimit:       viper.GetInt("ARXIV_PDF_DOWNLOAD_LIMIT"),
+		RefreshLookback:        viper.GetDuration("ARXIV_REFRESH_LOOKBACK"),
 		SearchStart:            viper.GetInt("ARXIV_SEARCH_START"),
 		SortBy:                 viper.GetString("ARXIV_SORT_BY"),
 		SortOrder:              viper.GetString("ARXIV_SORT_ORDER"),
+		TaxonomyMaxAge:         viper.GetDuration("ARXIV_TAXONOMY_MAX_AGE"),
 	}
 }
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+	"time"
+)
+
+type StorageConfig struct {
+	VisitedTTL time.Duration
+}
+
+var Storage *StorageConfig
+
+func loadStorageConfig() {
+	Storage = &StorageConfig{
+		VisitedTTL: viper.GetDuration("STORAGE_VISITED_TTL"),
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// Affiliation is an organisation stated for an author on a paper
+type Affiliation struct {
+	AuthorOrder int `db:"author_order"`
+	Position    int `db:"position"` // among the author's affiliations on the paper
+
+	AuthorId       ID `db:"author_id"`
+	OrganisationId ID `db:"organisation_id"`
+	PaperId        ID `db:"paper_id"`
+}
+
+// AffiliationPeriod is an author's organisation with the publication dates of the first and last papers stating it
+type AffiliationPeriod struct {
+	OrganisationId ID        `db:"organisation_id"`
+	Name           string    `db:"name"`
+	RorId          *string   `db:"ror_id"`
+	PaperCount     int       `db:"paper_count"`
+	FirstSeenAt    time.Time `db:"first_seen_at"`
+	LastSeenAt     time.Time `db:"last_seen_at"`
+}
+
+const affiliationsTable = "affiliations"
+
+var affiliationsColumns = []string{
+	"author_id",
+	"organisation_id",
+	"paper_id",
+	"author_order",
+	"position",
+}
+
+// saveAffiliationsTx saves the paper's affiliations, the authors and organisations must be saved first
+func saveAffiliationsTx(tx pgx.Tx, paper *Paper) error {
+	log.Debug("saving the affiliations")
+
+	affiliationCount := 0
+	var affiliationValues []interface{}
+	for authorOrder, author := range paper.Authors {
+		for position, organisation := range author.Organisations {
+			affiliationCount++
+			affiliationValues = append(affiliationValues, author.Id, organisation.Id, paper.Id, authorOrder, position)
+		}
+	}
+	if affiliationCount == 0 {
+		return nil
+	}
+
+	affiliationPlaceholder := generateInsertPlaceholder(len(affiliationsColumns), affiliationCount, 1)
+	affiliationsQuery := "INSERT INTO " + affiliationsTable + " (" + strings.Join(affiliationsColumns, ", ") + ") VALUES " + affiliationPlaceholder + " ON CONFLICT DO NOTHING"
+
+	_, err := tx.Exec(context.Background(), affiliationsQuery, affiliationValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the affiliations into the database: %w", err)
+	}
+
+	return nil
+}
+
+// GetAffiliationHistory returns the author's organisations over time (by first publication date)
+func GetAffiliationHistory(authorId ID) ([]*AffiliationPeriod, error) {
+	query := "SELECT o.id AS organisation_id, o.name, o.ror_id, count(DISTINCT af.paper_id) AS paper_count," +
+		" min(e.published_at) AS first_seen_at, max(e.published_at) AS last_seen_at" +
+		" FROM " + affiliationsTable + " af" +
+		" JOIN " + organisationsTable + " o ON o.id = af.organisation_id" +
+		" JOIN " + arxivEprintsTable + " e ON e.paper_id = af.paper_id" +
+		" WHERE af.author_id = $1 GROUP BY o.id, o.name, o.ror_id ORDER BY first_seen_at"
+
+	var periodList []*AffiliationPeriod
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &periodList, query, authorId)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the affiliation history of author %d: %w", authorId, err)
+	}
+
+	return periodList, nil
+}
@@ -118,6 +118,6 @@ func fetchAndUpdateArxivArchiveIds(archiveList []*ArxivArchive) error {
 
 func updateArchiveReferenceInArxivCategories(archive *ArxivArchive) {
 	for _, category := range archive.ArxivCategories {
-		category.ArxivArchiveId = archive.Id
+		category.ArxivArchiveId = &archive.Id
 	}
 }
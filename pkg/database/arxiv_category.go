@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
 	log "github.com/sirupsen/logrus"
 	"strings"
 	"time"
@@ -15,10 +16,14 @@ type ArxivCategory struct {
 	OriginalArxivCategoryDescription string `db:"original_arxiv_category_description"`
 	OriginalArxivCategoryName        string `db:"original_arxiv_category_name"`
 
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt *time.Time `db:"updated_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    *time.Time `db:"updated_at"`
+	DeprecatedAt *time.Time `db:"deprecated_at"`
 
-	ArxivArchiveId ID `db:"arxiv_archive_id"`
+	// provisional categories are registered from the eprints, not (yet) in the taxonomy
+	IsProvisional bool `db:"is_provisional"`
+
+	ArxivArchiveId *ID `db:"arxiv_archive_id"`
 
 	IsPrimary *bool
 
@@ -27,6 +32,9 @@ type ArxivCategory struct {
 
 const arxivCategoriesTable = "arxiv_categories"
 
+const arxivCategorySelect = "SELECT id, original_arxiv_category_code, original_arxiv_category_description, original_arxiv_category_name," +
+	" arxiv_archive_id, created_at, updated_at, deprecated_at, is_provisional FROM " + arxivCategoriesTable
+
 var arxivCategoriesColumns = []string{
 	"id",
 	"original_arxiv_category_code",
@@ -35,67 +43,211 @@ var arxivCategoriesColumns = []string{
 	"arxiv_archive_id",
 }
 
-func saveArxivCategories(categoryList []*ArxivCategory) error {
-	log.Debug("saving arXiv's categories")
+// ArxivTaxonomyChanges is the report of a taxonomy sync: the category codes added, updated (name, description or
+// archive), deprecated (no longer listed), restored (listed again) and reconciled (provisional, now listed)
+type ArxivTaxonomyChanges struct {
+	Added      []string
+	Updated    []string
+	Deprecated []string
+	Restored   []string
+	Reconciled []string
+}
 
-	var categoryValues []interface{}
-	for _, category := range categoryList {
-		categoryValues = append(categoryValues, category.OriginalArxivCategoryCode, category.OriginalArxivCategoryDescription, category.OriginalArxivCategoryName, category.ArxivArchiveId)
+// IsEmpty tells whether the taxonomy didn't change
+func (c *ArxivTaxonomyChanges) IsEmpty() bool {
+	return len(c.Added)+len(c.Updated)+len(c.Deprecated)+len(c.Restored)+len(c.Reconciled) == 0
+}
+
+// Log logs the change report
+func (c *ArxivTaxonomyChanges) Log() {
+	if c.IsEmpty() {
+		log.Info("no change in the arXiv's taxonomy")
+		return
+	}
+	log.Infof("arXiv's taxonomy changes: %d added, %d updated, %d deprecated, %d restored, %d reconciled",
+		len(c.Added), len(c.Updated), len(c.Deprecated), len(c.Restored), len(c.Reconciled))
+	for _, code := range c.Added {
+		log.Infof("added arXiv's category %s", code)
 	}
+	for _, code := range c.Updated {
+		log.Infof("updated arXiv's category %s", code)
+	}
+	for _, code := range c.Deprecated {
+		log.Warnf("deprecated arXiv's category %s (no longer in the taxonomy)", code)
+	}
+	for _, code := range c.Restored {
+		log.Infof("restored arXiv's category %s", code)
+	}
+	for _, code := range c.Reconciled {
+		log.Infof("reconciled the provisional arXiv's category %s with the taxonomy", code)
+	}
+}
+
+// syncArxivCategories diffs the scraped categories with the saved ones: the new ones are inserted, the changed ones
+// updated, the missing ones marked as deprecated (kept for the eprints referencing them)
+func syncArxivCategories(categoryList []*ArxivCategory) (*ArxivTaxonomyChanges, error) {
+	log.Debug("syncing arXiv's categories")
 
-	categoryPlaceholder := generateInsertPlaceholder(len(arxivCategoriesColumns[1:]), len(categoryList), 1)
-	categoriesQuery := "INSERT INTO " + arxivCategoriesTable + " (" + strings.Join(arxivCategoriesColumns[1:], ", ") + ") VALUES " + categoryPlaceholder + " ON CONFLICT DO NOTHING RETURNING id"
+	savedCategoryList, err := GetArxivCategoriesWithDeprecated()
+	if err != nil {
+		return nil, err
+	}
+	savedCategoryByCode := make(map[string]*ArxivCategory)
+	for _, savedCategory := range savedCategoryList {
+		savedCategoryByCode[savedCategory.OriginalArxivCategoryCode] = savedCategory
+	}
 
-	categoryRows, err := dbConnection.Pool.Query(context.Background(), categoriesQuery, categoryValues...)
-	defer categoryRows.Close()
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
 	if err != nil {
-		return fmt.Errorf("inserting the arXiv's categories into the database: %w", err)
+		return nil, err
 	}
+	defer tx.Rollback(context.Background())
 
-	insertedCategoryCount := 0
-	var insertedCategoryIdList []ID
-	for categoryRows.Next() {
-		var id ID
-		err = categoryRows.Scan(&id)
-		insertedCategoryIdList = append(insertedCategoryIdList, id)
+	changes := &ArxivTaxonomyChanges{}
+	now := time.Now()
+	scrapedCodeSet := make(map[string]struct{})
+	for _, category := range categoryList {
+		scrapedCodeSet[category.OriginalArxivCategoryCode] = struct{}{}
+
+		savedCategory, exists := savedCategoryByCode[category.OriginalArxivCategoryCode]
+		if !exists {
+			err = category.insertTx(tx)
+			if err != nil {
+				return nil, err
+			}
+			changes.Added = append(changes.Added, category.OriginalArxivCategoryCode)
+			continue
+		}
+
+		category.Id = savedCategory.Id
+		category.CreatedAt = savedCategory.CreatedAt
+		category.UpdatedAt = savedCategory.UpdatedAt
+		isChanged := category.OriginalArxivCategoryName != savedCategory.OriginalArxivCategoryName ||
+			category.OriginalArxivCategoryDescription != savedCategory.OriginalArxivCategoryDescription ||
+			!isSameId(category.ArxivArchiveId, savedCategory.ArxivArchiveId)
+		isRestored := savedCategory.DeprecatedAt != nil
+		isReconciled := savedCategory.IsProvisional
+		if isReconciled {
+			// the provisional name (the code) is replaced, not a rename
+			isChanged = false
+		}
+		if !isChanged && !isRestored && !isReconciled {
+			continue
+		}
+
+		category.UpdatedAt = &now
+		query := "UPDATE " + arxivCategoriesTable + " SET original_arxiv_category_name = $1, original_arxiv_category_description = $2," +
+			" arxiv_archive_id = $3, deprecated_at = NULL, is_provisional = false, updated_at = $4 WHERE id = $5"
+		_, err = tx.Exec(context.Background(), query, category.OriginalArxivCategoryName, category.OriginalArxivCategoryDescription, category.ArxivArchiveId, category.UpdatedAt, category.Id)
 		if err != nil {
-			return fmt.Errorf("scanning the arXiv's category ids: %w", err)
+			return nil, fmt.Errorf("updating the arXiv's category %s: %w", category.OriginalArxivCategoryCode, err)
+		}
+		if isChanged {
+			if category.OriginalArxivCategoryName != savedCategory.OriginalArxivCategoryName {
+				log.Infof("arXiv's category %s renamed from `%s` to `%s`", category.OriginalArxivCategoryCode, savedCategory.OriginalArxivCategoryName, category.OriginalArxivCategoryName)
+			}
+			changes.Updated = append(changes.Updated, category.OriginalArxivCategoryCode)
+		}
+		if isRestored {
+			changes.Restored = append(changes.Restored, category.OriginalArxivCategoryCode)
+		}
+		if isReconciled {
+			changes.Reconciled = append(changes.Reconciled, category.OriginalArxivCategoryCode)
 		}
-		insertedCategoryCount++
 	}
 
-	if insertedCategoryCount == len(categoryList) {
-		for i, id := range insertedCategoryIdList {
-			categoryList[i].Id = id
+	// the categories no longer listed are deprecated (the provisional ones never were)
+	for _, savedCategory := range savedCategoryList {
+		if _, exists := scrapedCodeSet[savedCategory.OriginalArxivCategoryCode]; exists || savedCategory.DeprecatedAt != nil || savedCategory.IsProvisional {
+			continue
 		}
-	} else {
-		err = fetchAndUpdateArxivCategoryIds(categoryList)
+		query := "UPDATE " + arxivCategoriesTable + " SET deprecated_at = $1, updated_at = $1 WHERE id = $2"
+		_, err = tx.Exec(context.Background(), query, now, savedCategory.Id)
 		if err != nil {
-			return fmt.Errorf("fetching the arXiv's category ids: %w", err)
+			return nil, fmt.Errorf("deprecating the arXiv's category %s: %w", savedCategory.OriginalArxivCategoryCode, err)
 		}
+		changes.Deprecated = append(changes.Deprecated, savedCategory.OriginalArxivCategoryCode)
+	}
+
+	// a partial taxonomy (e.g. page layout change) mustn't deprecate the categories
+	if len(changes.Deprecated) > len(categoryList) {
+		return nil, fmt.Errorf("%d categories would be deprecated for %d scraped, the taxonomy looks incomplete", len(changes.Deprecated), len(categoryList))
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("committing the transaction to sync the arXiv's categories: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (c *ArxivCategory) insertTx(tx pgx.Tx) error {
+	categoryPlaceholder := generateInsertPlaceholder(len(arxivCategoriesColumns[1:]), 1, 1)
+	categoryQuery := "INSERT INTO " + arxivCategoriesTable + " (" + strings.Join(arxivCategoriesColumns[1:], ", ") + ") VALUES " + categoryPlaceholder + " RETURNING id, created_at"
+
+	err := tx.QueryRow(context.Background(), categoryQuery, c.OriginalArxivCategoryCode, c.OriginalArxivCategoryDescription, c.OriginalArxivCategoryName, c.ArxivArchiveId).Scan(&c.Id, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting the arXiv's category %s into the database: %w", c.OriginalArxivCategoryCode, err)
 	}
 
 	return nil
 }
 
-func fetchAndUpdateArxivCategoryIds(categoryList []*ArxivCategory) error {
-	query := "SELECT id, original_arxiv_category_code FROM " + arxivCategoriesTable
-	var fetchedCategoryList []*ArxivCategory
-	err := pgxscan.Select(context.Background(), dbConnection.Pool, &fetchedCategoryList, query)
+// GetArxivCategoriesWithDeprecated returns all the saved categories, including the deprecated ones
+func GetArxivCategoriesWithDeprecated() ([]*ArxivCategory, error) {
+	query := arxivCategorySelect
+	var categoryList []*ArxivCategory
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &categoryList, query)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the arXiv's category list: %w", err)
+	}
+
+	return categoryList, nil
+}
+
+// GetArxivCategories returns the saved categories still in the taxonomy (i.e. not deprecated)
+func GetArxivCategories() ([]*ArxivCategory, error) {
+	query := arxivCategorySelect + " WHERE deprecated_at IS NULL"
+	var categoryList []*ArxivCategory
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &categoryList, query)
 	if err != nil {
-		return fmt.Errorf("scanning the arxiv category list: %w", err)
+		return nil, fmt.Errorf("scanning the arXiv's category list: %w", err)
 	}
 
-	arxivCategoryIdMapByCode := make(map[string]ID)
-	for _, arxivCategory := range fetchedCategoryList {
-		arxivCategoryIdMapByCode[arxivCategory.OriginalArxivCategoryCode] = arxivCategory.Id
+	return categoryList, nil
+}
+
+// RegisterProvisionalArxivCategory returns the category of the code, registering it as provisional if unknown
+// (e.g. a category used by an eprint but not in the taxonomy), in the archive of the code's prefix if known
+func RegisterProvisionalArxivCategory(code string) (*ArxivCategory, error) {
+	archiveCode := strings.SplitN(code, ".", 2)[0]
+	insertQuery := "INSERT INTO " + arxivCategoriesTable + " (original_arxiv_category_code, original_arxiv_category_description, original_arxiv_category_name," +
+		" arxiv_archive_id, is_provisional) VALUES ($1, '', $1, (SELECT id FROM " + arxivArchivesTable + " WHERE original_arxiv_archive_code = $2), true)" +
+		" ON CONFLICT DO NOTHING"
+	commandTag, err := dbConnection.Pool.Exec(context.Background(), insertQuery, code, archiveCode)
+	if err != nil {
+		return nil, fmt.Errorf("inserting the provisional arXiv's category %s: %w", code, err)
+	}
+	if commandTag.RowsAffected() > 0 {
+		log.Infof("registered the provisional arXiv's category %s", code)
 	}
 
-	for _, category := range categoryList {
-		if category.Id == 0 {
-			category.Id = arxivCategoryIdMapByCode[category.OriginalArxivCategoryCode]
-		}
+	var category ArxivCategory
+	query := arxivCategorySelect + " WHERE original_arxiv_category_code = $1"
+	err = pgxscan.Get(context.Background(), dbConnection.Pool, &category, query, code)
+	if err != nil {
+		return nil, fmt.Errorf("fetching the arXiv's category %s: %w", code, err)
 	}
 
-	return nil
+	return &category, nil
+}
+
+func isSameId(id1 *ID, id2 *ID) bool {
+	if id1 == nil || id2 == nil {
+		return id1 == id2
+	}
+	return *id1 == *id2
 }
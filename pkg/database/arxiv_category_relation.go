@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// ArxivCategoryRelation links a category code to the one it stands for: an alias (e.g. `math.IT` is an alias for
+// `cs.IT`) or a subsumed legacy archive (e.g. `solv-int` merged into `nlin.SI`)
+// The codes aren't foreign keys, the legacy archives aren't in the taxonomy anymore
+type ArxivCategoryRelation struct {
+	CategoryCode        string `db:"category_code"`
+	RelatedCategoryCode string `db:"related_category_code"`
+	RelationType        string `db:"relation_type"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+const arxivCategoryRelationsTable = "arxiv_category_relations"
+
+const (
+	AliasCategoryRelation    = "alias"
+	SubsumedCategoryRelation = "subsumed"
+)
+
+var arxivCategoryRelationsColumns = []string{
+	"category_code",
+	"related_category_code",
+	"relation_type",
+	"created_at",
+}
+
+// SaveArxivCategoryRelations saves the relations not known yet, and returns them
+func SaveArxivCategoryRelations(relationList []*ArxivCategoryRelation) ([]*ArxivCategoryRelation, error) {
+	if len(relationList) == 0 {
+		return nil, nil
+	}
+	log.Debug("saving arXiv's category relations")
+
+	var relationValues []interface{}
+	now := time.Now()
+	for _, relation := range relationList {
+		relation.CreatedAt = now
+		relationValues = append(relationValues, relation.CategoryCode, relation.RelatedCategoryCode, relation.RelationType, relation.CreatedAt)
+	}
+
+	relationPlaceholder := generateInsertPlaceholder(len(arxivCategoryRelationsColumns), len(relationList), 1)
+	relationsQuery := "INSERT INTO " + arxivCategoryRelationsTable + " (" + strings.Join(arxivCategoryRelationsColumns, ", ") + ") VALUES " + relationPlaceholder +
+		" ON CONFLICT DO NOTHING RETURNING " + strings.Join(arxivCategoryRelationsColumns, ", ")
+
+	var insertedRelationList []*ArxivCategoryRelation
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &insertedRelationList, relationsQuery, relationValues...)
+	if err != nil {
+		return nil, fmt.Errorf("inserting the arXiv's category relations into the database: %w", err)
+	}
+
+	return insertedRelationList, nil
+}
+
+// GetArxivCategoryRelations returns the known category relations
+func GetArxivCategoryRelations() ([]*ArxivCategoryRelation, error) {
+	query := "SELECT " + strings.Join(arxivCategoryRelationsColumns, ", ") + " FROM " + arxivCategoryRelationsTable + " ORDER BY category_code"
+
+	var relationList []*ArxivCategoryRelation
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &relationList, query)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the arXiv's category relations: %w", err)
+	}
+
+	return relationList, nil
+}
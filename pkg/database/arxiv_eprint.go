@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgx/v4"
+	"github.com/papetier/scraper/pkg/arxivid"
 	log "github.com/sirupsen/logrus"
 	"strings"
 	"time"
@@ -19,6 +21,20 @@ type ArxivEprint struct {
 	LatestVersion int                     `db:"latest_version"`
 	PdfLink       *string                 `db:"pdf_link"`
 
+	// license URL, with its SPDX-like identifier (e.g. `CC-BY-4.0`) if known
+	License   *string `db:"license"`
+	LicenseId *string `db:"license_id"`
+
+	IsWithdrawn      bool     `db:"is_withdrawn"`
+	WithdrawalReason *string  `db:"withdrawal_reason"`
+	ReportNumbers    []string `db:"report_numbers"`
+
+	// analysed from the comment
+	PageCount   *int     `db:"page_count"`
+	FigureCount *int     `db:"figure_count"`
+	TableCount  *int     `db:"table_count"`
+	CommentUrls []string `db:"comment_urls"`
+
 	PublishedAt time.Time `db:"published_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 
@@ -27,6 +43,8 @@ type ArxivEprint struct {
 	Paper                *Paper
 	PrimaryArxivCategory *ArxivCategory
 	OtherArxivCategories []*ArxivCategory
+	ClassificationCodes  []*ClassificationCode
+	VenueMentions        []*ArxivVenueMention
 }
 
 const (
@@ -34,6 +52,9 @@ const (
 	arxivEprintsArxivCategoriesTable = "arxiv_eptrins_arxiv_categories"
 )
 
+// the version suffix of the arXiv ids stored with their version (see MigrateVersionedArxivIds)
+const versionSuffixPattern = `v[0-9]+$`
+
 var arxivEprintsColumns = []string{
 	"id",
 	"arxiv_id",
@@ -44,6 +65,15 @@ var arxivEprintsColumns = []string{
 	"pdf_link",
 	"published_at",
 	"updated_at",
+	"license",
+	"license_id",
+	"is_withdrawn",
+	"withdrawal_reason",
+	"report_numbers",
+	"page_count",
+	"figure_count",
+	"table_count",
+	"comment_urls",
 }
 
 var arxivEprintsArxivCategoriesColumns = []string{
@@ -81,19 +111,42 @@ func (a *ArxivEprint) SaveWithPaperAuthorsAndCategories() (bool, error) {
 	}
 	defer tx.Rollback(context.Background())
 
-	// save authors w/ organisations
-	err = saveAuthorsWithOrganisationsTx(tx, a.Paper.Authors)
+	// the paper may already be known from another source (same identifier, e.g. DOI)
+	existingPaperId, err := findPaperIdByIdentifiersTx(tx, a.Paper.Identifiers)
 	if err != nil {
-		return false, fmt.Errorf("saving the authors with their organisations associated with the arXiv's eprint's `%s`: %w", a.ArxivId, err)
+		return false, fmt.Errorf("looking for an existing paper with the identifiers of the arXiv's eprint `%s`: %w", a.ArxivId, err)
 	}
 
-	// save paper with author links (and author order)
-	err = a.Paper.SaveWithAuthorsTx(tx)
-	if err != nil {
-		return false, fmt.Errorf("saving the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	if existingPaperId != nil {
+		log.Debugf("the arXiv's eprint `%s` has an identifier of paper %d", a.ArxivId, *existingPaperId)
+		a.Paper.Id = *existingPaperId
+	} else {
+		// save authors w/ organisations
+		err = saveAuthorsWithOrganisationsTx(tx, a.Paper.Authors, a.getCategoryIds())
+		if err != nil {
+			return false, fmt.Errorf("saving the authors with their organisations associated with the arXiv's eprint's `%s`: %w", a.ArxivId, err)
+		}
+
+		// save paper with author links (and author order)
+		err = a.Paper.SaveWithAuthorsTx(tx)
+		if err != nil {
+			return false, fmt.Errorf("saving the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+		}
 	}
 	a.PaperId = a.Paper.Id
 
+	// save the paper's identifiers
+	err = a.Paper.saveIdentifiersTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the identifiers of the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	// save the paper's links
+	err = a.Paper.saveLinksTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the links of the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
 	// save arxiv_eprint with categories
 	err = a.saveWithCategoriesTx(tx)
 	if err != nil {
@@ -110,6 +163,124 @@ func (a *ArxivEprint) SaveWithPaperAuthorsAndCategories() (bool, error) {
 	return false, nil
 }
 
+// UpdateWithPaperAndCategories updates the saved eprint, its paper and categories when the eprint is a newer version
+// (i.e. updated since or with a higher version), and returns whether it was updated
+// The authors are left as is (they're disambiguated on the first save)
+func (a *ArxivEprint) UpdateWithPaperAndCategories() (bool, error) {
+	return a.updateWithPaperAndCategories(false)
+}
+
+// ReplaceWithPaperAndCategories updates the saved eprint, its paper and categories unless the eprint is an older
+// version, i.e. also when it's the same version (e.g. reparsed from the archive with the current parsers), and returns
+// whether it was updated
+func (a *ArxivEprint) ReplaceWithPaperAndCategories() (bool, error) {
+	return a.updateWithPaperAndCategories(true)
+}
+
+func (a *ArxivEprint) updateWithPaperAndCategories(isSameVersionReplaced bool) (bool, error) {
+	var savedEprint ArxivEprint
+	query := "SELECT id, paper_id, latest_version, updated_at FROM " + arxivEprintsTable + " WHERE arxiv_id = $1"
+	err := dbConnection.Pool.QueryRow(context.Background(), query, a.ArxivId).Scan(&savedEprint.Id, &savedEprint.PaperId, &savedEprint.LatestVersion, &savedEprint.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("fetching the saved arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+	isNewer := a.UpdatedAt.After(savedEprint.UpdatedAt) || a.LatestVersion > savedEprint.LatestVersion
+	isOlder := a.UpdatedAt.Before(savedEprint.UpdatedAt) || a.LatestVersion < savedEprint.LatestVersion
+	if !isNewer && (!isSameVersionReplaced || isOlder) {
+		return false, nil
+	}
+	log.Debugf("updating arXiv's eprint `%s` (v%d to v%d)", a.ArxivId, savedEprint.LatestVersion, a.LatestVersion)
+
+	a.Id = savedEprint.Id
+	a.PaperId = savedEprint.PaperId
+	a.Paper.Id = savedEprint.PaperId
+
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.Background())
+
+	updateQuery := "UPDATE " + arxivEprintsTable + " SET comment = $1, extra = $2, latest_version = $3, pdf_link = $4, updated_at = $5," +
+		" license = COALESCE($6, license), license_id = COALESCE($7, license_id), is_withdrawn = $8, withdrawal_reason = $9, report_numbers = $10," +
+		" page_count = $11, figure_count = $12, table_count = $13, comment_urls = $14 WHERE id = $15"
+	_, err = tx.Exec(context.Background(), updateQuery, a.Comment, a.Extra, a.LatestVersion, a.PdfLink, a.UpdatedAt,
+		a.License, a.LicenseId, a.IsWithdrawn, a.WithdrawalReason, a.ReportNumbers, a.PageCount, a.FigureCount, a.TableCount, a.CommentUrls, a.Id)
+	if err != nil {
+		return false, fmt.Errorf("updating the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	err = a.Paper.updateTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("updating the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	err = a.Paper.saveIdentifiersTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the identifiers of the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	// save the paper's links
+	err = a.Paper.saveLinksTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the links of the paper associated with the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	// replace the categories (they may be cross-listed after the submission)
+	deleteQuery := "DELETE FROM " + arxivEprintsArxivCategoriesTable + " WHERE arxiv_eprint_id = $1"
+	_, err = tx.Exec(context.Background(), deleteQuery, a.Id)
+	if err != nil {
+		return false, fmt.Errorf("deleting the categories of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+	if a.PrimaryArxivCategory != nil || len(a.OtherArxivCategories) > 0 {
+		err = a.saveCategoriesTx(tx)
+		if err != nil {
+			return false, fmt.Errorf("saving the categories of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+		}
+	}
+	deleteQuery = "DELETE FROM " + arxivEprintsClassificationCodesTable + " WHERE arxiv_eprint_id = $1"
+	_, err = tx.Exec(context.Background(), deleteQuery, a.Id)
+	if err != nil {
+		return false, fmt.Errorf("deleting the classification codes of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+	err = a.saveClassificationCodesTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the classification codes of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	// replace the venue mentions (the comment is usually updated on acceptance)
+	deleteQuery = "DELETE FROM " + arxivEprintsVenueMentionsTable + " WHERE arxiv_eprint_id = $1"
+	_, err = tx.Exec(context.Background(), deleteQuery, a.Id)
+	if err != nil {
+		return false, fmt.Errorf("deleting the venue mentions of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+	err = a.saveVenueMentionsTx(tx)
+	if err != nil {
+		return false, fmt.Errorf("saving the venue mentions of the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("committing the transaction to update the arXiv's eprint `%s`: %w", a.ArxivId, err)
+	}
+
+	log.Infof("successfully updated arXiv's eprint %s (v%d)", a.ArxivId, a.LatestVersion)
+	return true, nil
+}
+
+func (a *ArxivEprint) getCategoryIds() []ID {
+	var categoryIdList []ID
+	if a.PrimaryArxivCategory != nil {
+		categoryIdList = append(categoryIdList, a.PrimaryArxivCategory.Id)
+	}
+	for _, category := range a.OtherArxivCategories {
+		categoryIdList = append(categoryIdList, category.Id)
+	}
+	return categoryIdList
+}
+
 func (a *ArxivEprint) saveWithCategoriesTx(tx pgx.Tx) error {
 	// save arxiv_eprint
 	err := a.saveTx(tx)
@@ -125,6 +296,18 @@ func (a *ArxivEprint) saveWithCategoriesTx(tx pgx.Tx) error {
 		}
 	}
 
+	// save links arxiv_eprint/classification codes
+	err = a.saveClassificationCodesTx(tx)
+	if err != nil {
+		return fmt.Errorf("saving the arxiv_eprints_classification_codes: %w", err)
+	}
+
+	// save the venue mentions
+	err = a.saveVenueMentionsTx(tx)
+	if err != nil {
+		return fmt.Errorf("saving the arxiv_eprints_venue_mentions: %w", err)
+	}
+
 	return nil
 }
 
@@ -134,7 +317,8 @@ func (a *ArxivEprint) saveTx(tx pgx.Tx) error {
 	arxivEprintPlaceholder := generateInsertPlaceholder(len(arxivEprintsColumns[1:]), 1, 1)
 	arxivEprintsQuery := "INSERT INTO " + arxivEprintsTable + " (" + strings.Join(arxivEprintsColumns[1:], ", ") + ") VALUES " + arxivEprintPlaceholder + " RETURNING id"
 
-	arxivEprintRow, err := tx.Query(context.Background(), arxivEprintsQuery, a.ArxivId, a.Paper.Id, a.Comment, a.Extra, a.LatestVersion, a.PdfLink, a.PublishedAt, a.UpdatedAt)
+	arxivEprintRow, err := tx.Query(context.Background(), arxivEprintsQuery, a.ArxivId, a.Paper.Id, a.Comment, a.Extra, a.LatestVersion, a.PdfLink, a.PublishedAt, a.UpdatedAt,
+		a.License, a.LicenseId, a.IsWithdrawn, a.WithdrawalReason, a.ReportNumbers, a.PageCount, a.FigureCount, a.TableCount, a.CommentUrls)
 	defer arxivEprintRow.Close()
 	if err != nil {
 		return fmt.Errorf("inserting the arxiv_eprint into the database: %w", err)
@@ -175,3 +359,54 @@ func (a *ArxivEprint) saveCategoriesTx(tx pgx.Tx) error {
 
 	return nil
 }
+
+// GetArxivId returns the identifier of the eprint's latest version
+func (a *ArxivEprint) GetArxivId() (*arxivid.ID, error) {
+	id, err := arxivid.Parse(a.ArxivId)
+	if err != nil {
+		return nil, err
+	}
+	if a.LatestVersion > 0 {
+		id = id.WithVersion(a.LatestVersion)
+	}
+	return id, nil
+}
+
+// FindPaperIdByArxivId returns the id of the paper of the arXiv's eprint (any identifier form, e.g. with version or URL), nil if none
+func FindPaperIdByArxivId(arxivId string) (*ID, error) {
+	id, err := arxivid.Parse(arxivId)
+	if err != nil {
+		return nil, nil
+	}
+
+	query := "SELECT paper_id FROM " + arxivEprintsTable + " WHERE arxiv_id = $1"
+	return findId(query, id.Base)
+}
+
+// MigrateVersionedArxivIds replaces the arXiv ids stored with their version (e.g. `1706.03762v5`, before the base ids
+// were stored, the version being kept in latest_version) by their base id, and returns the migrated count
+// The versioned ids whose base id is already stored (i.e. the eprint was saved twice) are left as is and logged
+func MigrateVersionedArxivIds() (int64, error) {
+	baseId := "regexp_replace(%s.arxiv_id, '" + versionSuffixPattern + "', '')"
+	query := "UPDATE " + arxivEprintsTable + " e SET arxiv_id = " + fmt.Sprintf(baseId, "e") +
+		" WHERE e.arxiv_id ~ '" + versionSuffixPattern + "'" +
+		" AND NOT EXISTS (SELECT 1 FROM " + arxivEprintsTable + " b WHERE b.arxiv_id = " + fmt.Sprintf(baseId, "e") + ")" +
+		// a single row per base id (the oldest one) if several versions were stored
+		" AND e.id = (SELECT min(v.id) FROM " + arxivEprintsTable + " v WHERE v.arxiv_id ~ '" + versionSuffixPattern + "' AND " + fmt.Sprintf(baseId, "v") + " = " + fmt.Sprintf(baseId, "e") + ")"
+	result, err := dbConnection.Pool.Exec(context.Background(), query)
+	if err != nil {
+		return 0, fmt.Errorf("migrating the versioned arXiv ids: %w", err)
+	}
+
+	var conflictList []string
+	conflictQuery := "SELECT arxiv_id FROM " + arxivEprintsTable + " WHERE arxiv_id ~ '" + versionSuffixPattern + "' ORDER BY arxiv_id"
+	err = pgxscan.Select(context.Background(), dbConnection.Pool, &conflictList, conflictQuery)
+	if err != nil {
+		return 0, fmt.Errorf("scanning the versioned arXiv ids left: %w", err)
+	}
+	for _, arxivId := range conflictList {
+		log.Warnf("the versioned arXiv id %s wasn't migrated: its base id is already stored", arxivId)
+	}
+
+	return result.RowsAffected(), nil
+}
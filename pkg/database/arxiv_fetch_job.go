@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"strings"
+	"time"
+)
+
+// ArxivFetchJob is an arXiv id queued to be fetched on demand (see the `fetch` command)
+type ArxivFetchJob struct {
+	Id ID `db:"id"`
+
+	ArxivId string  `db:"arxiv_id"`
+	Status  string  `db:"status"`
+	Message *string `db:"message"`
+
+	CreatedAt time.Time  `db:"created_at"`
+	FetchedAt *time.Time `db:"fetched_at"`
+}
+
+const arxivFetchJobsTable = "arxiv_fetch_jobs"
+
+const (
+	PendingFetchJobStatus  = "pending"
+	FetchedFetchJobStatus  = "fetched"
+	NotFoundFetchJobStatus = "not_found"
+	ErrorFetchJobStatus    = "error"
+)
+
+var arxivFetchJobsColumns = []string{
+	"id",
+	"arxiv_id",
+	"status",
+	"message",
+	"created_at",
+	"fetched_at",
+}
+
+// AddArxivFetchJobs queues the arXiv ids to fetch (once per id, the ids already queued are ignored)
+func AddArxivFetchJobs(arxivIdList []string) error {
+	if len(arxivIdList) == 0 {
+		return nil
+	}
+
+	var jobValues []interface{}
+	now := time.Now()
+	for _, arxivId := range arxivIdList {
+		jobValues = append(jobValues, arxivId, PendingFetchJobStatus, now)
+	}
+
+	placeholder := generateInsertPlaceholder(3, len(arxivIdList), 1)
+	query := "INSERT INTO " + arxivFetchJobsTable + " (arxiv_id, status, created_at) VALUES " + placeholder + " ON CONFLICT DO NOTHING"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, jobValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the arXiv's fetch jobs: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingArxivFetchJobs returns the oldest pending fetch jobs
+func GetPendingArxivFetchJobs(limit int) ([]*ArxivFetchJob, error) {
+	query := "SELECT " + strings.Join(arxivFetchJobsColumns, ", ") + " FROM " + arxivFetchJobsTable +
+		" WHERE status = $1 ORDER BY id LIMIT $2"
+
+	var jobList []*ArxivFetchJob
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &jobList, query, PendingFetchJobStatus, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the pending arXiv's fetch jobs: %w", err)
+	}
+
+	return jobList, nil
+}
+
+// UpdateStatus records the outcome of the fetch job
+func (j *ArxivFetchJob) UpdateStatus(status string, message *string) error {
+	now := time.Now()
+	j.Status = status
+	j.Message = message
+	j.FetchedAt = &now
+
+	query := "UPDATE " + arxivFetchJobsTable + " SET status = $1, message = $2, fetched_at = $3 WHERE id = $4"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, j.Status, j.Message, j.FetchedAt, j.Id)
+	if err != nil {
+		return fmt.Errorf("updating the arXiv's fetch job %d: %w", j.Id, err)
+	}
+
+	return nil
+}
@@ -22,7 +22,9 @@ var arxivGroupsColumns = []string{
 	"original_arxiv_group_name",
 }
 
-func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) error {
+// SaveArxivGroupsArchivesAndCategories saves the taxonomy: the groups and archives are inserted if new, the categories
+// synced (see ArxivTaxonomyChanges)
+func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) (*ArxivTaxonomyChanges, error) {
 	log.Debug("saving arXiv's groups, archives and categories")
 
 	var groupValues []interface{}
@@ -36,7 +38,7 @@ func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) error {
 	groupRows, err := dbConnection.Pool.Query(context.Background(), groupsQuery, groupValues...)
 	defer groupRows.Close()
 	if err != nil {
-		return fmt.Errorf("inserting the arXiv's groups into the database: %w", err)
+		return nil, fmt.Errorf("inserting the arXiv's groups into the database: %w", err)
 	}
 
 	insertedGroupCount := 0
@@ -46,7 +48,7 @@ func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) error {
 		err = groupRows.Scan(&id)
 		insertedGroupIdList = append(insertedGroupIdList, id)
 		if err != nil {
-			return fmt.Errorf("scanning the arXiv's group ids: %w", err)
+			return nil, fmt.Errorf("scanning the arXiv's group ids: %w", err)
 		}
 		insertedGroupCount++
 	}
@@ -59,7 +61,7 @@ func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) error {
 	} else {
 		err = fetchAndUpdateArxivGroupIds(groupList)
 		if err != nil {
-			return fmt.Errorf("fetching the arXiv's group ids: %w", err)
+			return nil, fmt.Errorf("fetching the arXiv's group ids: %w", err)
 		}
 	}
 
@@ -76,16 +78,16 @@ func SaveArxivGroupsArchivesAndCategories(groupList []*ArxivGroup) error {
 	// save the archives
 	err = saveArxivArchives(archiveList)
 	if err != nil {
-		return fmt.Errorf("saving the arXiv's archives: %w", err)
+		return nil, fmt.Errorf("saving the arXiv's archives: %w", err)
 	}
 
-	// save the categories
-	err = saveArxivCategories(categoryList)
+	// sync the categories
+	changes, err := syncArxivCategories(categoryList)
 	if err != nil {
-		return fmt.Errorf("saving the arXiv's categories: %w", err)
+		return nil, fmt.Errorf("syncing the arXiv's categories: %w", err)
 	}
 
-	return nil
+	return changes, nil
 }
 
 func fetchAndUpdateArxivGroupIds(groupList []*ArxivGroup) error {
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// the refresh watermark of an arXiv category is the last update date of its eprints already refreshed
+const arxivRefreshWatermarksTable = "arxiv_refresh_watermarks"
+
+// GetArxivRefreshWatermark returns the refresh watermark of the category, nil if never refreshed
+func GetArxivRefreshWatermark(categoryCode string) (*time.Time, error) {
+	var updatedUntil time.Time
+	query := "SELECT updated_until FROM " + arxivRefreshWatermarksTable + " WHERE category_code = $1"
+	err := dbConnection.Pool.QueryRow(context.Background(), query, categoryCode).Scan(&updatedUntil)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching the refresh watermark of category %s: %w", categoryCode, err)
+	}
+
+	return &updatedUntil, nil
+}
+
+// SaveArxivRefreshWatermark sets the refresh watermark of the category
+func SaveArxivRefreshWatermark(categoryCode string, updatedUntil time.Time) error {
+	query := "INSERT INTO " + arxivRefreshWatermarksTable + " (category_code, updated_until, refreshed_at) VALUES ($1, $2, now())" +
+		" ON CONFLICT (category_code) DO UPDATE SET updated_until = EXCLUDED.updated_until, refreshed_at = EXCLUDED.refreshed_at"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, categoryCode, updatedUntil)
+	if err != nil {
+		return fmt.Errorf("saving the refresh watermark of category %s: %w", categoryCode, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// the taxonomy syncs record where the categories were last loaded from, and when
+const arxivTaxonomySyncsTable = "arxiv_taxonomy_syncs"
+
+const (
+	LiveTaxonomySource     = "live"
+	SnapshotTaxonomySource = "snapshot"
+)
+
+// SaveArxivTaxonomySync records a sync of the taxonomy from the source (with the snapshot's version, if any)
+func SaveArxivTaxonomySync(source string, version *string, changes *ArxivTaxonomyChanges) error {
+	query := "INSERT INTO " + arxivTaxonomySyncsTable + " (source, version, added_count, updated_count, deprecated_count, restored_count, synced_at)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, now())"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, source, version, len(changes.Added), len(changes.Updated), len(changes.Deprecated), len(changes.Restored))
+	if err != nil {
+		return fmt.Errorf("saving the arXiv's taxonomy sync: %w", err)
+	}
+
+	return nil
+}
+
+// GetLastArxivTaxonomySyncedAt returns the date of the last sync from the live taxonomy, nil if none
+func GetLastArxivTaxonomySyncedAt() (*time.Time, error) {
+	var syncedAt time.Time
+	query := "SELECT synced_at FROM " + arxivTaxonomySyncsTable + " WHERE source = $1 ORDER BY synced_at DESC LIMIT 1"
+	err := dbConnection.Pool.QueryRow(context.Background(), query, LiveTaxonomySource).Scan(&syncedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching the last arXiv's taxonomy sync: %w", err)
+	}
+
+	return &syncedAt, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+// ArxivVenueMention is a venue mentioned in the comment of an eprint (e.g. `accepted at NeurIPS 2021`), linked to the
+// venue when it's a known one
+type ArxivVenueMention struct {
+	ArxivEprintId ID   `db:"arxiv_eprint_id"`
+	VenueId       *ID  `db:"venue_id"`
+	Year          *int `db:"year"`
+
+	Status  string `db:"status"`
+	Mention string `db:"mention"`
+
+	Venue *Venue
+}
+
+// the statuses of the venue mentions
+const (
+	AcceptedMentionStatus  = "accepted"
+	ToAppearMentionStatus  = "to_appear"
+	PublishedMentionStatus = "published"
+	PresentedMentionStatus = "presented"
+)
+
+const arxivEprintsVenueMentionsTable = "arxiv_eprints_venue_mentions"
+
+var arxivEprintsVenueMentionsColumns = []string{
+	"arxiv_eprint_id",
+	"venue_id",
+	"year",
+	"status",
+	"mention",
+}
+
+// saveVenueMentionsTx saves the venue mentions of the eprint (with their venue, if known)
+func (a *ArxivEprint) saveVenueMentionsTx(tx pgx.Tx) error {
+	if len(a.VenueMentions) == 0 {
+		return nil
+	}
+
+	var mentionValues []interface{}
+	for _, mention := range a.VenueMentions {
+		if mention.Venue != nil {
+			err := mention.Venue.saveWithPublisherTx(tx)
+			if err != nil {
+				return fmt.Errorf("saving the venue: %w", err)
+			}
+			mention.VenueId = &mention.Venue.Id
+		}
+		mention.ArxivEprintId = a.Id
+		mentionValues = append(mentionValues, mention.ArxivEprintId, mention.VenueId, mention.Year, mention.Status, mention.Mention)
+	}
+
+	mentionPlaceholder := generateInsertPlaceholder(len(arxivEprintsVenueMentionsColumns), len(a.VenueMentions), 1)
+	mentionsQuery := "INSERT INTO " + arxivEprintsVenueMentionsTable + " (" + strings.Join(arxivEprintsVenueMentionsColumns, ", ") + ") VALUES " + mentionPlaceholder
+
+	_, err := tx.Exec(context.Background(), mentionsQuery, mentionValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the arxiv_eprints_venue_mentions into the database: %w", err)
+	}
+
+	return nil
+}
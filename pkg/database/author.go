@@ -3,8 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
-	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgx/v4"
+	"github.com/papetier/scraper/pkg/personname"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
@@ -14,6 +14,15 @@ type Author struct {
 	Email    *string `db:"email"`
 	FullName string  `db:"full_name"`
 
+	// parsed from the full name, see NewAuthor
+	GivenName    string `db:"given_name"`
+	NameParticle string `db:"name_particle"`
+	FamilyName   string `db:"family_name"`
+	NameSuffix   string `db:"name_suffix"`
+	NameKey      string `db:"name_key"`
+
+	MergedIntoId *ID `db:"merged_into_id"`
+
 	Organisations []*Organisation
 }
 
@@ -26,6 +35,12 @@ var authorsColumns = []string{
 	"id",
 	"email",
 	"full_name",
+	"given_name",
+	"name_particle",
+	"family_name",
+	"name_suffix",
+	"name_key",
+	"merged_into_id",
 }
 
 var authorsOrganisationsColumns = []string{
@@ -33,18 +48,35 @@ var authorsOrganisationsColumns = []string{
 	"organisation_id",
 }
 
-func saveAuthorsWithOrganisationsTx(tx pgx.Tx, authorList []*Author) error {
+// NewAuthor returns an author with the raw full name (as stated on the paper) and its parsed parts
+func NewAuthor(fullName string) *Author {
+	name := personname.Parse(fullName)
+	return &Author{
+		FullName:     fullName,
+		GivenName:    name.Given,
+		NameParticle: name.Particle,
+		FamilyName:   name.Family,
+		NameSuffix:   name.Suffix,
+		NameKey:      name.Key(),
+	}
+}
+
+// saveAuthorsWithOrganisationsTx saves the organisations and resolves the authors' identities
+// The paper's categories are used as evidence to tell apart the authors with similar names
+func saveAuthorsWithOrganisationsTx(tx pgx.Tx, authorList []*Author, categoryIdList []ID) error {
 	log.Debug("saving authors with their organisations")
 
-	// get unique organisation list
+	// get unique organisation list (by name), the authors sharing an organisation share the same instance
 	var organisationList []*Organisation
-	organisationSet := make(map[string]struct{})
+	organisationMapByName := make(map[string]*Organisation)
 	for _, author := range authorList {
-		for _, organisation := range author.Organisations {
-			if _, exists := organisationSet[organisation.Name]; !exists {
-				organisationList = append(organisationList, author.Organisations...)
-				organisationSet[organisation.Name] = struct{}{}
+		for i, organisation := range author.Organisations {
+			if existingOrganisation, exists := organisationMapByName[organisation.Name]; exists {
+				author.Organisations[i] = existingOrganisation
+				continue
 			}
+			organisationList = append(organisationList, organisation)
+			organisationMapByName[organisation.Name] = organisation
 		}
 	}
 
@@ -56,23 +88,10 @@ func saveAuthorsWithOrganisationsTx(tx pgx.Tx, authorList []*Author) error {
 		}
 	}
 
-	// re-map author's organisations
-	// if several authors had same organisation
-	for _, author := range authorList {
-		for i, authorOrganisation := range author.Organisations {
-			for _, organisation := range organisationList {
-				if organisation.Name == authorOrganisation.Name {
-					author.Organisations[i] = organisation
-					break
-				}
-			}
-		}
-	}
-
-	// save authors
-	err := saveAuthorsTx(tx, authorList)
+	// resolve authors (existing identity or new author)
+	err := resolveAuthorsTx(tx, authorList, categoryIdList)
 	if err != nil {
-		return fmt.Errorf("saving the authors: %w", err)
+		return fmt.Errorf("resolving the authors: %w", err)
 	}
 
 	// save the authors/organisations links
@@ -86,76 +105,6 @@ func saveAuthorsWithOrganisationsTx(tx pgx.Tx, authorList []*Author) error {
 	return nil
 }
 
-func saveAuthorsTx(tx pgx.Tx, authorList []*Author) error {
-	log.Debug("saving authors")
-
-	var authorValues []interface{}
-	for _, author := range authorList {
-		authorValues = append(authorValues, author.Email, author.FullName)
-	}
-
-	authorPlaceholder := generateInsertPlaceholder(len(authorsColumns[1:]), len(authorList), 1)
-	authorsQuery := "INSERT INTO " + authorsTable + " (" + strings.Join(authorsColumns[1:], ", ") + ") VALUES " + authorPlaceholder + " ON CONFLICT DO NOTHING RETURNING id"
-
-	authorRows, err := tx.Query(context.Background(), authorsQuery, authorValues...)
-	defer authorRows.Close()
-	if err != nil {
-		return fmt.Errorf("inserting the authors into the database: %w", err)
-	}
-
-	insertedAuthorCount := 0
-	var insertedAuthorIdList []ID
-	for authorRows.Next() {
-		var id ID
-		err = authorRows.Scan(&id)
-		insertedAuthorIdList = append(insertedAuthorIdList, id)
-		if err != nil {
-			return fmt.Errorf("scanning the author ids: %w", err)
-		}
-		insertedAuthorCount++
-	}
-
-	if insertedAuthorCount == len(authorList) {
-		for i, id := range insertedAuthorIdList {
-			authorList[i].Id = id
-		}
-	} else {
-		err = fetchAndUpdateAuthorIdsTx(tx, authorList)
-		if err != nil {
-			return fmt.Errorf("fetching the author ids: %w", err)
-		}
-	}
-
-	return nil
-}
-
-func fetchAndUpdateAuthorIdsTx(tx pgx.Tx, authorList []*Author) error {
-	placeholder := generateInsertPlaceholder(len(authorList), 1, 1)
-	query := "SELECT id, full_name FROM " + authorsTable + " WHERE full_name IN " + placeholder
-	var parameters []interface{}
-	for _, author := range authorList {
-		parameters = append(parameters, author.FullName)
-	}
-	var fetchedAuthorList []*Author
-	err := pgxscan.Select(context.Background(), tx, &fetchedAuthorList, query, parameters...)
-	if err != nil {
-		return fmt.Errorf("scanning the author list: %w", err)
-	}
-
-	authorIdMapByName := make(map[string]ID)
-	for _, author := range fetchedAuthorList {
-		authorIdMapByName[author.FullName] = author.Id
-	}
-
-	for _, author := range authorList {
-		if author.Id == 0 {
-			author.Id = authorIdMapByName[author.FullName]
-		}
-	}
-
-	return nil
-}
-
 func saveAuthorsOrganisationsTx(tx pgx.Tx, authorList []*Author) error {
 	log.Debug("saving the authors_organisations links")
 
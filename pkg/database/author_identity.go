@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/papetier/scraper/pkg/personname"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"unicode/utf8"
+)
+
+// AuthorMention is an author as stated on a paper, clustered into an author identity (i.e. authors.id)
+type AuthorMention struct {
+	Id          ID       `db:"id"`
+	AuthorOrder int      `db:"author_order"`
+	FullName    string   `db:"full_name"`
+	NameKey     string   `db:"name_key"`
+	BlockKey    string   `db:"block_key"`
+	Affiliation []string `db:"affiliations"`
+
+	AuthorId ID `db:"author_id"`
+	PaperId  ID `db:"paper_id"`
+}
+
+const authorMentionsTable = "author_mentions"
+
+var authorMentionsColumns = []string{
+	"id",
+	"paper_id",
+	"author_id",
+	"author_order",
+	"full_name",
+	"name_key",
+	"block_key",
+	"affiliations",
+}
+
+// clustering weights: a mention joins an existing author when the evidence reaches the threshold, with at least a shared
+// co-author or affiliation (the name and categories alone don't tell apart the namesakes of a field)
+const (
+	authorMatchThreshold     = 1.5
+	exactNameScore           = 1.0
+	compatibleNameScore      = 0.5
+	uniqueFullNameScore      = 0.5
+	sharedCoAuthorScore      = 1.0
+	maxSharedCoAuthorScore   = 3.0
+	sharedAffiliationScore   = 1.0
+	sharedCategoryScore      = 0.5
+	maxSharedCategoryScore   = 1.0
+	minFullGivenNameLength   = 2
+	authorCandidateLimit     = 50
+	authorCandidateNameLimit = 20
+)
+
+// authorEvidence is what is known about a new mention to compare it with the existing authors
+type authorEvidence struct {
+	coAuthorBlockKeys []string
+	affiliations      []string
+	categoryIds       []ID
+}
+
+type authorCandidate struct {
+	Id        ID       `db:"id"`
+	NameList  []string `db:"name_list"`
+	NameCount int      `db:"name_count"`
+}
+
+// resolveAuthorsTx assigns an author identity to every author of a paper: an existing author if the evidence
+// (name variants, co-authors, affiliations, categories) is strong enough, a new one otherwise
+func resolveAuthorsTx(tx pgx.Tx, authorList []*Author, categoryIdList []ID) error {
+	log.Debug("resolving the authors' identities")
+
+	assignedAuthorIds := make(map[ID]struct{})
+	for i, author := range authorList {
+		evidence := &authorEvidence{
+			categoryIds: categoryIdList,
+		}
+		for j, coAuthor := range authorList {
+			if i != j {
+				evidence.coAuthorBlockKeys = append(evidence.coAuthorBlockKeys, authorBlockKey(coAuthor.FullName))
+			}
+		}
+		for _, organisation := range author.Organisations {
+			evidence.affiliations = append(evidence.affiliations, organisation.Name)
+		}
+
+		authorId, err := findMatchingAuthorTx(tx, author, evidence, assignedAuthorIds)
+		if err != nil {
+			return fmt.Errorf("matching the author `%s`: %w", author.FullName, err)
+		}
+
+		if authorId != nil {
+			author.Id = *authorId
+		} else {
+			err = author.insertTx(tx)
+			if err != nil {
+				return fmt.Errorf("inserting the author `%s`: %w", author.FullName, err)
+			}
+		}
+		assignedAuthorIds[author.Id] = struct{}{}
+	}
+
+	return nil
+}
+
+func findMatchingAuthorTx(tx pgx.Tx, author *Author, evidence *authorEvidence, excludedAuthorIds map[ID]struct{}) (*ID, error) {
+	candidateQuery := "SELECT a.id, (array_agg(DISTINCT m.full_name))[1:" + fmt.Sprint(authorCandidateNameLimit) + "] AS name_list, count(DISTINCT m.full_name) AS name_count" +
+		" FROM " + authorsTable + " a JOIN " + authorMentionsTable + " m ON m.author_id = a.id" +
+		" WHERE m.block_key = $1 AND a.merged_into_id IS NULL GROUP BY a.id LIMIT " + fmt.Sprint(authorCandidateLimit)
+	var candidateList []*authorCandidate
+	err := pgxscan.Select(context.Background(), tx, &candidateList, candidateQuery, authorBlockKey(author.FullName))
+	if err != nil {
+		return nil, fmt.Errorf("scanning the author candidates: %w", err)
+	}
+
+	nameKey := authorNameKey(author.FullName)
+	var bestAuthorId *ID
+	bestScore := 0.0
+	for _, candidate := range candidateList {
+		if _, isExcluded := excludedAuthorIds[candidate.Id]; isExcluded {
+			continue
+		}
+
+		// name evidence
+		score := 0.0
+		isExactName := false
+		isCompatible := false
+		for _, candidateName := range candidate.NameList {
+			if authorNameKey(candidateName) == nameKey {
+				isExactName = true
+			}
+			if areAuthorNamesCompatible(candidateName, author.FullName) {
+				isCompatible = true
+			}
+		}
+		switch {
+		case isExactName:
+			score += exactNameScore
+			if len(candidateList) == 1 && hasFullGivenName(author.FullName) {
+				score += uniqueFullNameScore
+			}
+		case isCompatible:
+			score += compatibleNameScore
+		default:
+			// e.g. `John Smith` vs `James Smith`
+			continue
+		}
+
+		// context evidence
+		contextScore, hasSharedCoAuthorOrAffiliation, err := getAuthorContextScoreTx(tx, candidate.Id, evidence)
+		if err != nil {
+			return nil, err
+		}
+		if !hasSharedCoAuthorOrAffiliation {
+			continue
+		}
+		score += contextScore
+
+		if score >= authorMatchThreshold && score > bestScore {
+			bestScore = score
+			candidateId := candidate.Id
+			bestAuthorId = &candidateId
+		}
+	}
+
+	return bestAuthorId, nil
+}
+
+// getAuthorContextScoreTx returns the context score of the author, and whether they share a co-author or affiliation
+func getAuthorContextScoreTx(tx pgx.Tx, authorId ID, evidence *authorEvidence) (float64, bool, error) {
+	var sharedCoAuthorCount, sharedAffiliationCount, sharedCategoryCount int
+
+	coAuthorQuery := "SELECT count(DISTINCT m2.block_key) FROM " + authorMentionsTable + " m1 JOIN " + authorMentionsTable + " m2 ON m2.paper_id = m1.paper_id AND m2.author_id <> m1.author_id" +
+		" WHERE m1.author_id = $1 AND m2.block_key = ANY($2)"
+	err := tx.QueryRow(context.Background(), coAuthorQuery, authorId, evidence.coAuthorBlockKeys).Scan(&sharedCoAuthorCount)
+	if err != nil {
+		return 0, false, fmt.Errorf("counting the shared co-authors of author %d: %w", authorId, err)
+	}
+
+	affiliationQuery := "SELECT count(*) FROM " + authorMentionsTable + " WHERE author_id = $1 AND affiliations && $2"
+	err = tx.QueryRow(context.Background(), affiliationQuery, authorId, evidence.affiliations).Scan(&sharedAffiliationCount)
+	if err != nil {
+		return 0, false, fmt.Errorf("counting the shared affiliations of author %d: %w", authorId, err)
+	}
+
+	categoryQuery := "SELECT count(DISTINCT ec.arxiv_category_id) FROM " + authorMentionsTable + " m" +
+		" JOIN " + arxivEprintsTable + " e ON e.paper_id = m.paper_id" +
+		" JOIN " + arxivEprintsArxivCategoriesTable + " ec ON ec.arxiv_eprint_id = e.id" +
+		" WHERE m.author_id = $1 AND ec.arxiv_category_id = ANY($2)"
+	err = tx.QueryRow(context.Background(), categoryQuery, authorId, evidence.categoryIds).Scan(&sharedCategoryCount)
+	if err != nil {
+		return 0, false, fmt.Errorf("counting the shared categories of author %d: %w", authorId, err)
+	}
+
+	score := minFloat(float64(sharedCoAuthorCount)*sharedCoAuthorScore, maxSharedCoAuthorScore)
+	if sharedAffiliationCount > 0 {
+		score += sharedAffiliationScore
+	}
+	score += minFloat(float64(sharedCategoryCount)*sharedCategoryScore, maxSharedCategoryScore)
+
+	return score, sharedCoAuthorCount > 0 || sharedAffiliationCount > 0, nil
+}
+
+func (a *Author) insertTx(tx pgx.Tx) error {
+	authorPlaceholder := generateInsertPlaceholder(len(authorsColumns[1:]), 1, 1)
+	authorsQuery := "INSERT INTO " + authorsTable + " (" + strings.Join(authorsColumns[1:], ", ") + ") VALUES " + authorPlaceholder + " RETURNING id"
+
+	return tx.QueryRow(context.Background(), authorsQuery, a.Email, a.FullName, a.GivenName, a.NameParticle, a.FamilyName, a.NameSuffix, a.NameKey, a.MergedIntoId).Scan(&a.Id)
+}
+
+// saveAuthorMentionsTx saves the paper's authors as stated on the paper, with their assigned identity
+func saveAuthorMentionsTx(tx pgx.Tx, paper *Paper) error {
+	log.Debug("saving the author mentions")
+
+	if len(paper.Authors) == 0 {
+		return nil
+	}
+
+	var mentionValues []interface{}
+	for order, author := range paper.Authors {
+		var affiliations []string
+		for _, organisation := range author.Organisations {
+			affiliations = append(affiliations, organisation.Name)
+		}
+		mentionValues = append(mentionValues, paper.Id, author.Id, order, author.FullName, authorNameKey(author.FullName), authorBlockKey(author.FullName), affiliations)
+	}
+
+	mentionPlaceholder := generateInsertPlaceholder(len(authorMentionsColumns[1:]), len(paper.Authors), 1)
+	mentionsQuery := "INSERT INTO " + authorMentionsTable + " (" + strings.Join(authorMentionsColumns[1:], ", ") + ") VALUES " + mentionPlaceholder
+
+	_, err := tx.Exec(context.Background(), mentionsQuery, mentionValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the author mentions into the database: %w", err)
+	}
+
+	return nil
+}
+
+// authorNameKey is the comparable form of a full name (ASCII-folded, lower case, no punctuation)
+func authorNameKey(fullName string) string {
+	return personname.Parse(fullName).Key()
+}
+
+// authorBlockKey groups the names which may be variants of each other: first initial + family name
+func authorBlockKey(fullName string) string {
+	name := personname.Parse(fullName)
+	initials := name.Initials()
+	if initials == "" {
+		return name.FamilyKey()
+	}
+	// the first rune (the keys of the non-Latin names, e.g. Cyrillic or CJK, aren't folded to ASCII)
+	initial, _ := utf8.DecodeRuneInString(initials)
+	return string(initial) + " " + name.FamilyKey()
+}
+
+// areAuthorNamesCompatible checks that every given name matches (same name, or initial of the other)
+func areAuthorNamesCompatible(firstFullName, secondFullName string) bool {
+	firstName := personname.Parse(firstFullName)
+	secondName := personname.Parse(secondFullName)
+	if firstName.FamilyKey() != secondName.FamilyKey() || firstName.Suffix != secondName.Suffix {
+		return false
+	}
+
+	firstGivenNames := firstName.GivenKeys()
+	secondGivenNames := secondName.GivenKeys()
+	for i := 0; i < len(firstGivenNames) && i < len(secondGivenNames); i++ {
+		first, second := firstGivenNames[i], secondGivenNames[i]
+		if first == second {
+			continue
+		}
+		if (len(first) == 1 && strings.HasPrefix(second, first)) || (len(second) == 1 && strings.HasPrefix(first, second)) {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
+
+func hasFullGivenName(fullName string) bool {
+	givenNames := personname.Parse(fullName).GivenKeys()
+	return len(givenNames) > 0 && len(givenNames[0]) >= minFullGivenNameLength
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
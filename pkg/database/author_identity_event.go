@@ -0,0 +1,338 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// AuthorIdentityEvent is a manual correction of the author clustering
+// The events reference the mentions (not the authors), so they can be replayed after a re-clustering:
+//   - merge: the authors of the mentions are merged into the author of the anchor mention
+//   - split: the mentions are moved to a new author
+type AuthorIdentityEvent struct {
+	Id ID `db:"id"`
+
+	EventType       string `db:"event_type"`
+	AnchorMentionId *ID    `db:"anchor_mention_id"`
+	MentionIds      []ID   `db:"mention_ids"`
+	Note            string `db:"note"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+const (
+	authorIdentityEventsTable = "author_identity_events"
+	mergeAuthorEventType      = "merge"
+	splitAuthorEventType      = "split"
+)
+
+var authorIdentityEventsColumns = []string{
+	"id",
+	"event_type",
+	"anchor_mention_id",
+	"mention_ids",
+	"note",
+	"created_at",
+}
+
+// MergeAuthors merges the source authors into the target author and records the event
+func MergeAuthors(targetAuthorId ID, sourceAuthorIdList []ID, note string) error {
+	log.Infof("merging the authors %v into author %d", sourceAuthorIdList, targetAuthorId)
+
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	event := &AuthorIdentityEvent{
+		EventType: mergeAuthorEventType,
+		Note:      note,
+	}
+	event.AnchorMentionId, err = getAnchorMentionIdTx(tx, targetAuthorId)
+	if err != nil {
+		return err
+	}
+	if event.AnchorMentionId == nil {
+		return fmt.Errorf("the author %d has no mention", targetAuthorId)
+	}
+
+	mentionQuery := "SELECT id FROM " + authorMentionsTable + " WHERE author_id = ANY($1) ORDER BY id"
+	err = pgxscan.Select(context.Background(), tx, &event.MentionIds, mentionQuery, sourceAuthorIdList)
+	if err != nil {
+		return fmt.Errorf("scanning the mentions of the source authors: %w", err)
+	}
+
+	err = mergeAuthorsTx(tx, targetAuthorId, sourceAuthorIdList)
+	if err != nil {
+		return err
+	}
+
+	err = event.saveTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to merge the authors: %w", err)
+	}
+
+	return nil
+}
+
+// SplitAuthor moves the author's mentions to a new author, records the event and returns the new author's id
+func SplitAuthor(authorId ID, mentionIdList []ID, note string) (ID, error) {
+	log.Infof("splitting the mentions %v from author %d", mentionIdList, authorId)
+
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+
+	newAuthorId, err := splitAuthorTx(tx, authorId, mentionIdList)
+	if err != nil {
+		return 0, err
+	}
+
+	event := &AuthorIdentityEvent{
+		EventType:  splitAuthorEventType,
+		MentionIds: mentionIdList,
+		Note:       note,
+	}
+	err = event.saveTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("committing the transaction to split the author %d: %w", authorId, err)
+	}
+
+	return newAuthorId, nil
+}
+
+// ReplayAuthorIdentityEvents re-applies the recorded merges and splits (in order) and returns how many changed the clustering
+func ReplayAuthorIdentityEvents() (int, error) {
+	query := "SELECT " + strings.Join(authorIdentityEventsColumns, ", ") + " FROM " + authorIdentityEventsTable + " ORDER BY id"
+	var eventList []*AuthorIdentityEvent
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &eventList, query)
+	if err != nil {
+		return 0, fmt.Errorf("scanning the author identity events: %w", err)
+	}
+
+	appliedCount := 0
+	for _, event := range eventList {
+		isApplied, err := event.replay()
+		if err != nil {
+			return appliedCount, fmt.Errorf("replaying the author identity event %d: %w", event.Id, err)
+		}
+		if isApplied {
+			appliedCount++
+		}
+	}
+
+	return appliedCount, nil
+}
+
+func (e *AuthorIdentityEvent) replay() (bool, error) {
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.Background())
+
+	isApplied := false
+	switch e.EventType {
+	case mergeAuthorEventType:
+		if e.AnchorMentionId == nil {
+			return false, fmt.Errorf("merge event without anchor mention")
+		}
+		var targetAuthorId ID
+		err = tx.QueryRow(context.Background(), "SELECT author_id FROM "+authorMentionsTable+" WHERE id = $1", *e.AnchorMentionId).Scan(&targetAuthorId)
+		if err != nil {
+			return false, fmt.Errorf("fetching the author of the anchor mention %d: %w", *e.AnchorMentionId, err)
+		}
+
+		var sourceAuthorIdList []ID
+		sourceQuery := "SELECT DISTINCT author_id FROM " + authorMentionsTable + " WHERE id = ANY($1) AND author_id <> $2"
+		err = pgxscan.Select(context.Background(), tx, &sourceAuthorIdList, sourceQuery, e.MentionIds, targetAuthorId)
+		if err != nil {
+			return false, fmt.Errorf("scanning the authors to merge: %w", err)
+		}
+
+		if len(sourceAuthorIdList) > 0 {
+			err = mergeAuthorsTx(tx, targetAuthorId, sourceAuthorIdList)
+			if err != nil {
+				return false, err
+			}
+			isApplied = true
+		}
+	case splitAuthorEventType:
+		// only the authors still having other mentions than the split ones need a split
+		var authorIdList []ID
+		authorQuery := "SELECT DISTINCT m.author_id FROM " + authorMentionsTable + " m WHERE m.id = ANY($1)" +
+			" AND EXISTS (SELECT 1 FROM " + authorMentionsTable + " o WHERE o.author_id = m.author_id AND NOT o.id = ANY($1))"
+		err = pgxscan.Select(context.Background(), tx, &authorIdList, authorQuery, e.MentionIds)
+		if err != nil {
+			return false, fmt.Errorf("scanning the authors to split: %w", err)
+		}
+
+		for _, authorId := range authorIdList {
+			_, err = splitAuthorTx(tx, authorId, e.MentionIds)
+			if err != nil {
+				return false, err
+			}
+			isApplied = true
+		}
+	default:
+		return false, fmt.Errorf("unknown event type `%s`", e.EventType)
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("committing the transaction to replay the event: %w", err)
+	}
+
+	return isApplied, nil
+}
+
+func (e *AuthorIdentityEvent) saveTx(tx pgx.Tx) error {
+	e.CreatedAt = time.Now()
+	placeholder := generateInsertPlaceholder(len(authorIdentityEventsColumns[1:]), 1, 1)
+	query := "INSERT INTO " + authorIdentityEventsTable + " (" + strings.Join(authorIdentityEventsColumns[1:], ", ") + ") VALUES " + placeholder + " RETURNING id"
+
+	err := tx.QueryRow(context.Background(), query, e.EventType, e.AnchorMentionId, e.MentionIds, e.Note, e.CreatedAt).Scan(&e.Id)
+	if err != nil {
+		return fmt.Errorf("inserting the author identity event: %w", err)
+	}
+
+	return nil
+}
+
+func getAnchorMentionIdTx(tx pgx.Tx, authorId ID) (*ID, error) {
+	var mentionIdList []ID
+	query := "SELECT id FROM " + authorMentionsTable + " WHERE author_id = $1 ORDER BY id LIMIT 1"
+	err := pgxscan.Select(context.Background(), tx, &mentionIdList, query, authorId)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the mentions of author %d: %w", authorId, err)
+	}
+	if len(mentionIdList) == 0 {
+		return nil, nil
+	}
+	return &mentionIdList[0], nil
+}
+
+// mergeAuthorsTx moves the mentions, paper links and organisation links of the sources to the target
+// The target must not be merged itself nor among the sources; the sources' authorships of a same paper are collapsed
+// (the first in the author order is kept)
+func mergeAuthorsTx(tx pgx.Tx, targetAuthorId ID, sourceAuthorIdList []ID) error {
+	var uniqueSourceIdList []ID
+	sourceIdSet := make(map[ID]struct{})
+	for _, sourceAuthorId := range sourceAuthorIdList {
+		if sourceAuthorId == targetAuthorId {
+			return fmt.Errorf("the author %d can't be merged into itself", targetAuthorId)
+		}
+		if _, exists := sourceIdSet[sourceAuthorId]; !exists {
+			uniqueSourceIdList = append(uniqueSourceIdList, sourceAuthorId)
+			sourceIdSet[sourceAuthorId] = struct{}{}
+		}
+	}
+	sourceAuthorIdList = uniqueSourceIdList
+
+	var mergedIntoId *ID
+	err := tx.QueryRow(context.Background(), "SELECT merged_into_id FROM "+authorsTable+" WHERE id = $1", targetAuthorId).Scan(&mergedIntoId)
+	if err != nil {
+		return fmt.Errorf("fetching the target author %d: %w", targetAuthorId, err)
+	}
+	if mergedIntoId != nil {
+		return fmt.Errorf("the target author %d was already merged into author %d", targetAuthorId, *mergedIntoId)
+	}
+
+	queryList := []string{
+		"UPDATE " + authorMentionsTable + " SET author_id = $1 WHERE author_id = ANY($2)",
+		// an author appears once per paper: the sources' authorships of the target's papers, then the ones of a same paper
+		"DELETE FROM " + papersAuthorsTable + " WHERE author_id = ANY($2) AND paper_id IN (SELECT paper_id FROM " + papersAuthorsTable + " WHERE author_id = $1)",
+		"DELETE FROM " + papersAuthorsTable + " pa USING " + papersAuthorsTable + " other WHERE pa.author_id = ANY($2) AND other.author_id = ANY($2)" +
+			" AND other.paper_id = pa.paper_id AND (other.author_order, other.author_id) < (pa.author_order, pa.author_id)",
+		"UPDATE " + papersAuthorsTable + " SET author_id = $1 WHERE author_id = ANY($2)",
+		"INSERT INTO " + authorsOrganisationsTable + " (author_id, organisation_id) SELECT $1, organisation_id FROM " + authorsOrganisationsTable + " WHERE author_id = ANY($2) ON CONFLICT DO NOTHING",
+		// likewise, an organisation appears once per author and paper
+		"DELETE FROM " + affiliationsTable + " af USING " + affiliationsTable + " other WHERE af.author_id = ANY($2)" +
+			" AND other.paper_id = af.paper_id AND other.organisation_id = af.organisation_id" +
+			" AND (other.author_id = $1 OR (other.author_id = ANY($2) AND (other.author_order, other.author_id) < (af.author_order, af.author_id)))",
+		"UPDATE " + affiliationsTable + " SET author_id = $1 WHERE author_id = ANY($2)",
+		"UPDATE " + authorsTable + " SET merged_into_id = $1 WHERE id = ANY($2) OR merged_into_id = ANY($2)",
+	}
+
+	for _, query := range queryList {
+		_, err = tx.Exec(context.Background(), query, targetAuthorId, sourceAuthorIdList)
+		if err != nil {
+			return fmt.Errorf("merging the authors %v into author %d: %w", sourceAuthorIdList, targetAuthorId, err)
+		}
+	}
+
+	return nil
+}
+
+// splitAuthorTx moves the author's given mentions (and related paper links) to a new author
+func splitAuthorTx(tx pgx.Tx, authorId ID, mentionIdList []ID) (ID, error) {
+	var mentionList []*AuthorMention
+	mentionQuery := "SELECT " + strings.Join(authorMentionsColumns, ", ") + " FROM " + authorMentionsTable + " WHERE id = ANY($1) AND author_id = $2 ORDER BY id"
+	err := pgxscan.Select(context.Background(), tx, &mentionList, mentionQuery, mentionIdList, authorId)
+	if err != nil {
+		return 0, fmt.Errorf("scanning the mentions to split: %w", err)
+	}
+	if len(mentionList) == 0 {
+		return 0, fmt.Errorf("none of the mentions %v belongs to author %d", mentionIdList, authorId)
+	}
+
+	newAuthor := NewAuthor(mentionList[0].FullName)
+	err = newAuthor.insertTx(tx)
+	if err != nil {
+		return 0, fmt.Errorf("inserting the split author: %w", err)
+	}
+
+	linksQuery := "UPDATE " + papersAuthorsTable + " pa SET author_id = $1 FROM " + authorMentionsTable + " m" +
+		" WHERE m.id = ANY($2) AND m.author_id = $3 AND pa.paper_id = m.paper_id AND pa.author_id = $3"
+	_, err = tx.Exec(context.Background(), linksQuery, newAuthor.Id, mentionIdList, authorId)
+	if err != nil {
+		return 0, fmt.Errorf("moving the papers_authors links of the split mentions: %w", err)
+	}
+
+	affiliationsQuery := "UPDATE " + affiliationsTable + " af SET author_id = $1 FROM " + authorMentionsTable + " m" +
+		" WHERE m.id = ANY($2) AND m.author_id = $3 AND af.paper_id = m.paper_id AND af.author_id = $3"
+	_, err = tx.Exec(context.Background(), affiliationsQuery, newAuthor.Id, mentionIdList, authorId)
+	if err != nil {
+		return 0, fmt.Errorf("moving the affiliations of the split mentions: %w", err)
+	}
+
+	mentionsUpdateQuery := "UPDATE " + authorMentionsTable + " SET author_id = $1 WHERE id = ANY($2) AND author_id = $3"
+	_, err = tx.Exec(context.Background(), mentionsUpdateQuery, newAuthor.Id, mentionIdList, authorId)
+	if err != nil {
+		return 0, fmt.Errorf("moving the split mentions: %w", err)
+	}
+
+	// the new author is linked to the organisations of its mentions' affiliations
+	organisationsQuery := "INSERT INTO " + authorsOrganisationsTable + " (author_id, organisation_id) SELECT $1, o.id FROM " + organisationsTable + " o" +
+		" WHERE o.name IN (SELECT unnest(affiliations) FROM " + authorMentionsTable + " WHERE author_id = $1) ON CONFLICT DO NOTHING"
+	_, err = tx.Exec(context.Background(), organisationsQuery, newAuthor.Id)
+	if err != nil {
+		return 0, fmt.Errorf("linking the split author to its organisations: %w", err)
+	}
+
+	return newAuthor.Id, nil
+}
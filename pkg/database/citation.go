@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type Citation struct {
+	Id ID `db:"id"`
+
+	Position int    `db:"position"`
+	RawText  string `db:"raw_text"`
+
+	Authors []string `db:"authors"`
+	ArxivId *string  `db:"arxiv_id"`
+	Doi     *string  `db:"doi"`
+	Title   *string  `db:"title"`
+	Year    *int     `db:"year"`
+
+	CreatedAt  time.Time  `db:"created_at"`
+	ResolvedAt *time.Time `db:"resolved_at"`
+
+	CitingPaperId ID  `db:"citing_paper_id"`
+	CitedPaperId  *ID `db:"cited_paper_id"`
+}
+
+const citationsTable = "citations"
+
+var citationsColumns = []string{
+	"id",
+	"citing_paper_id",
+	"cited_paper_id",
+	"position",
+	"raw_text",
+	"authors",
+	"arxiv_id",
+	"doi",
+	"title",
+	"year",
+	"created_at",
+	"resolved_at",
+}
+
+// SaveCitationsForExtraction replaces the citations of the extraction's paper and marks its references as extracted
+func SaveCitationsForExtraction(extraction *DocumentExtraction, citationList []*Citation) error {
+	log.Debugf("saving %d citations of paper %d", len(citationList), extraction.PaperId)
+
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	deleteQuery := "DELETE FROM " + citationsTable + " WHERE citing_paper_id = $1"
+	_, err = tx.Exec(context.Background(), deleteQuery, extraction.PaperId)
+	if err != nil {
+		return fmt.Errorf("deleting the previous citations: %w", err)
+	}
+
+	if len(citationList) > 0 {
+		var citationValues []interface{}
+		for _, citation := range citationList {
+			citation.CitingPaperId = extraction.PaperId
+			citationValues = append(citationValues, citation.CitingPaperId, citation.CitedPaperId, citation.Position, citation.RawText, citation.Authors, citation.ArxivId, citation.Doi, citation.Title, citation.Year, citation.CreatedAt, citation.ResolvedAt)
+		}
+
+		citationPlaceholder := generateInsertPlaceholder(len(citationsColumns[1:]), len(citationList), 1)
+		citationsQuery := "INSERT INTO " + citationsTable + " (" + strings.Join(citationsColumns[1:], ", ") + ") VALUES " + citationPlaceholder
+		_, err = tx.Exec(context.Background(), citationsQuery, citationValues...)
+		if err != nil {
+			return fmt.Errorf("inserting the citations into the database: %w", err)
+		}
+	}
+
+	updateQuery := "UPDATE " + documentExtractionsTable + " SET references_extracted_at = now() WHERE id = $1"
+	_, err = tx.Exec(context.Background(), updateQuery, extraction.Id)
+	if err != nil {
+		return fmt.Errorf("marking the references of the extraction as extracted: %w", err)
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to save the citations of paper %d: %w", extraction.PaperId, err)
+	}
+
+	return nil
+}
+
+// Resolve links the citation to the cited paper
+func (c *Citation) Resolve(citedPaperId ID) error {
+	now := time.Now()
+	query := "UPDATE " + citationsTable + " SET cited_paper_id = $1, resolved_at = $2 WHERE id = $3"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, citedPaperId, now, c.Id)
+	if err != nil {
+		return fmt.Errorf("resolving the citation %d: %w", c.Id, err)
+	}
+
+	c.CitedPaperId = &citedPaperId
+	c.ResolvedAt = &now
+	return nil
+}
+
+// GetUnresolvedCitations returns the citations without cited paper, after the given id (for pagination)
+func GetUnresolvedCitations(afterId ID, limit int) ([]*Citation, error) {
+	query := "SELECT " + strings.Join(citationsColumns, ", ") + " FROM " + citationsTable +
+		" WHERE cited_paper_id IS NULL AND id > $1 ORDER BY id LIMIT $2"
+
+	var citationList []*Citation
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &citationList, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the unresolved citations: %w", err)
+	}
+
+	return citationList, nil
+}
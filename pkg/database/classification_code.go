@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// ClassificationCode is a code of a subject classification scheme (e.g. MSC 2020 `68T05`, ACM CCS `I.2.6`), see the
+// classification package; the codes form a tree through their parent code
+type ClassificationCode struct {
+	Id ID `db:"id"`
+
+	Scheme     string  `db:"scheme"`
+	Code       string  `db:"code"`
+	Name       *string `db:"name"`
+	ParentCode *string `db:"parent_code"`
+
+	// bundled codes come from the scheme's file, the others were only seen on eprints
+	IsBundled bool `db:"is_bundled"`
+
+	Parent *ClassificationCode
+}
+
+const (
+	classificationCodesTable             = "classification_codes"
+	arxivEprintsClassificationCodesTable = "arxiv_eprints_classification_codes"
+	classificationCodeKeySeparator       = " "
+	classificationCodesUpsertStatement   = " ON CONFLICT (scheme, code) DO UPDATE SET name = COALESCE(EXCLUDED.name, " + classificationCodesTable + ".name)," +
+		" parent_code = COALESCE(EXCLUDED.parent_code, " + classificationCodesTable + ".parent_code)," +
+		" is_bundled = " + classificationCodesTable + ".is_bundled OR EXCLUDED.is_bundled"
+)
+
+var classificationCodesColumns = []string{
+	"id",
+	"scheme",
+	"code",
+	"name",
+	"parent_code",
+	"is_bundled",
+}
+
+var arxivEprintsClassificationCodesColumns = []string{
+	"arxiv_eprint_id",
+	"classification_code_id",
+}
+
+// SaveClassificationCodes saves the codes (e.g. a bundled scheme), completing the known ones
+func SaveClassificationCodes(codeList []*ClassificationCode) error {
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = saveClassificationCodesTx(tx, codeList)
+	if err != nil {
+		return err
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to save the classification codes: %w", err)
+	}
+
+	return nil
+}
+
+// saveClassificationCodesTx upserts the codes (once per scheme and code) and sets their ids
+func saveClassificationCodesTx(tx pgx.Tx, codeList []*ClassificationCode) error {
+	if len(codeList) == 0 {
+		return nil
+	}
+	log.Debug("saving the classification codes")
+
+	var codeValues []interface{}
+	codeListByKey := make(map[string][]*ClassificationCode)
+	var uniqueCodeCount int
+	for _, code := range codeList {
+		key := code.Scheme + classificationCodeKeySeparator + code.Code
+		if _, exists := codeListByKey[key]; !exists {
+			codeValues = append(codeValues, code.Scheme, code.Code, code.Name, code.ParentCode, code.IsBundled)
+			uniqueCodeCount++
+		}
+		codeListByKey[key] = append(codeListByKey[key], code)
+	}
+
+	codePlaceholder := generateInsertPlaceholder(len(classificationCodesColumns[1:]), uniqueCodeCount, 1)
+	codesQuery := "INSERT INTO " + classificationCodesTable + " (" + strings.Join(classificationCodesColumns[1:], ", ") + ") VALUES " + codePlaceholder +
+		classificationCodesUpsertStatement + " RETURNING id, scheme, code"
+
+	codeRows, err := tx.Query(context.Background(), codesQuery, codeValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the classification codes into the database: %w", err)
+	}
+	defer codeRows.Close()
+
+	for codeRows.Next() {
+		var id ID
+		var scheme, code string
+		err = codeRows.Scan(&id, &scheme, &code)
+		if err != nil {
+			return fmt.Errorf("scanning the classification code ids: %w", err)
+		}
+		for _, classificationCode := range codeListByKey[scheme+classificationCodeKeySeparator+code] {
+			classificationCode.Id = id
+		}
+	}
+
+	return codeRows.Err()
+}
+
+// saveClassificationCodesTx links the eprint to its classification codes (saved with their ancestors)
+func (a *ArxivEprint) saveClassificationCodesTx(tx pgx.Tx) error {
+	if len(a.ClassificationCodes) == 0 {
+		return nil
+	}
+
+	var codeList []*ClassificationCode
+	for _, code := range a.ClassificationCodes {
+		for ancestor := code; ancestor != nil; ancestor = ancestor.Parent {
+			codeList = append(codeList, ancestor)
+		}
+	}
+	err := saveClassificationCodesTx(tx, codeList)
+	if err != nil {
+		return err
+	}
+
+	var linkValues []interface{}
+	for _, code := range a.ClassificationCodes {
+		linkValues = append(linkValues, a.Id, code.Id)
+	}
+	linkPlaceholder := generateInsertPlaceholder(len(arxivEprintsClassificationCodesColumns), len(a.ClassificationCodes), 1)
+	linksQuery := "INSERT INTO " + arxivEprintsClassificationCodesTable + " (" + strings.Join(arxivEprintsClassificationCodesColumns, ", ") + ") VALUES " + linkPlaceholder +
+		" ON CONFLICT DO NOTHING"
+
+	_, err = tx.Exec(context.Background(), linksQuery, linkValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the arxiv_eprints_classification_codes links into the database: %w", err)
+	}
+
+	return nil
+}
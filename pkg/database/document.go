@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type Document struct {
+	Id ID `db:"id"`
+
+	ContentType string `db:"content_type"`
+	Sha256      string `db:"sha256"`
+	Size        int64  `db:"size"`
+	StorageKey  string `db:"storage_key"`
+	Version     int    `db:"version"`
+
+	DownloadedAt time.Time `db:"downloaded_at"`
+
+	ArxivEprintId ID `db:"arxiv_eprint_id"`
+	PaperId       ID `db:"paper_id"`
+}
+
+const documentsTable = "documents"
+
+var documentsColumns = []string{
+	"id",
+	"arxiv_eprint_id",
+	"paper_id",
+	"content_type",
+	"sha256",
+	"size",
+	"storage_key",
+	"version",
+	"downloaded_at",
+}
+
+func (d *Document) Save() error {
+	log.Debugf("saving the document %s", d.StorageKey)
+
+	documentPlaceholder := generateInsertPlaceholder(len(documentsColumns[1:]), 1, 1)
+	documentsQuery := "INSERT INTO " + documentsTable + " (" + strings.Join(documentsColumns[1:], ", ") + ") VALUES " + documentPlaceholder +
+		" ON CONFLICT (arxiv_eprint_id, version) DO UPDATE SET content_type = EXCLUDED.content_type, sha256 = EXCLUDED.sha256, size = EXCLUDED.size, storage_key = EXCLUDED.storage_key, downloaded_at = EXCLUDED.downloaded_at RETURNING id"
+
+	documentRow, err := dbConnection.Pool.Query(context.Background(), documentsQuery, d.ArxivEprintId, d.PaperId, d.ContentType, d.Sha256, d.Size, d.StorageKey, d.Version, d.DownloadedAt)
+	defer documentRow.Close()
+	if err != nil {
+		return fmt.Errorf("inserting the document into the database: %w", err)
+	}
+
+	for documentRow.Next() {
+		err = documentRow.Scan(&d.Id)
+		if err != nil {
+			return fmt.Errorf("scanning the document id: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetArxivEprintsWithoutDocument returns the most recent arXiv's eprints whose latest version wasn't downloaded yet
+// (except the withdrawn ones, their latest version being the withdrawal notice)
+func GetArxivEprintsWithoutDocument(limit int) ([]*ArxivEprint, error) {
+	query := "SELECT e.id, e.arxiv_id, e.paper_id, e.latest_version, e.pdf_link FROM " + arxivEprintsTable + " e" +
+		" WHERE NOT e.is_withdrawn AND NOT EXISTS (SELECT 1 FROM " + documentsTable + " d WHERE d.arxiv_eprint_id = e.id AND d.version = e.latest_version)" +
+		" ORDER BY e.published_at DESC LIMIT $1"
+
+	var arxivEprintList []*ArxivEprint
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &arxivEprintList, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the arXiv's eprints without document: %w", err)
+	}
+
+	return arxivEprintList, nil
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type DocumentExtraction struct {
+	Id ID `db:"id"`
+
+	Error     *string `db:"error"`
+	PageCount int     `db:"page_count"`
+	Version   int     `db:"version"`
+
+	ExtractedAt time.Time `db:"extracted_at"`
+
+	DocumentId ID `db:"document_id"`
+	PaperId    ID `db:"paper_id"`
+
+	Pages []*DocumentPage
+}
+
+type DocumentPage struct {
+	PageNumber int    `db:"page_number"`
+	Text       string `db:"text"`
+
+	DocumentExtractionId ID `db:"document_extraction_id"`
+}
+
+const (
+	documentExtractionsTable = "document_extractions"
+	documentPagesTable       = "document_pages"
+)
+
+var documentExtractionsColumns = []string{
+	"id",
+	"document_id",
+	"paper_id",
+	"error",
+	"page_count",
+	"version",
+	"extracted_at",
+}
+
+var documentPagesColumns = []string{
+	"document_extraction_id",
+	"page_number",
+	"text",
+}
+
+// SaveWithPages saves the extraction result (success or failure) and replaces the previously extracted pages
+func (d *DocumentExtraction) SaveWithPages() error {
+	log.Debugf("saving the extraction of document %d", d.DocumentId)
+
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = d.saveTx(tx)
+	if err != nil {
+		return fmt.Errorf("saving the document extraction: %w", err)
+	}
+
+	err = d.savePagesTx(tx)
+	if err != nil {
+		return fmt.Errorf("saving the document pages: %w", err)
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to save the extraction of document %d: %w", d.DocumentId, err)
+	}
+
+	return nil
+}
+
+func (d *DocumentExtraction) saveTx(tx pgx.Tx) error {
+	extractionPlaceholder := generateInsertPlaceholder(len(documentExtractionsColumns[1:]), 1, 1)
+	extractionsQuery := "INSERT INTO " + documentExtractionsTable + " (" + strings.Join(documentExtractionsColumns[1:], ", ") + ") VALUES " + extractionPlaceholder +
+		" ON CONFLICT (document_id) DO UPDATE SET error = EXCLUDED.error, page_count = EXCLUDED.page_count, extracted_at = EXCLUDED.extracted_at RETURNING id"
+
+	extractionRow, err := tx.Query(context.Background(), extractionsQuery, d.DocumentId, d.PaperId, d.Error, d.PageCount, d.Version, d.ExtractedAt)
+	defer extractionRow.Close()
+	if err != nil {
+		return fmt.Errorf("inserting the document extraction into the database: %w", err)
+	}
+
+	for extractionRow.Next() {
+		err = extractionRow.Scan(&d.Id)
+		if err != nil {
+			return fmt.Errorf("scanning the document extraction id: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DocumentExtraction) savePagesTx(tx pgx.Tx) error {
+	deleteQuery := "DELETE FROM " + documentPagesTable + " WHERE document_extraction_id = $1"
+	_, err := tx.Exec(context.Background(), deleteQuery, d.Id)
+	if err != nil {
+		return fmt.Errorf("deleting the previously extracted pages: %w", err)
+	}
+
+	if len(d.Pages) == 0 {
+		return nil
+	}
+
+	var pageValues []interface{}
+	for _, page := range d.Pages {
+		page.DocumentExtractionId = d.Id
+		pageValues = append(pageValues, page.DocumentExtractionId, page.PageNumber, page.Text)
+	}
+
+	pagePlaceholder := generateInsertPlaceholder(len(documentPagesColumns), len(d.Pages), 1)
+	pagesQuery := "INSERT INTO " + documentPagesTable + " (" + strings.Join(documentPagesColumns, ", ") + ") VALUES " + pagePlaceholder
+
+	_, err = tx.Exec(context.Background(), pagesQuery, pageValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the document pages into the database: %w", err)
+	}
+
+	return nil
+}
+
+// GetDocumentsWithoutExtraction returns the documents whose text wasn't extracted yet, after the given id (for pagination)
+func GetDocumentsWithoutExtraction(afterId ID, limit int) ([]*Document, error) {
+	query := "SELECT d.id, d.arxiv_eprint_id, d.paper_id, d.content_type, d.sha256, d.size, d.storage_key, d.version, d.downloaded_at FROM " + documentsTable + " d" +
+		" WHERE d.id > $1 AND NOT EXISTS (SELECT 1 FROM " + documentExtractionsTable + " x WHERE x.document_id = d.id)" +
+		" ORDER BY d.id LIMIT $2"
+
+	var documentList []*Document
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &documentList, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the documents without extraction: %w", err)
+	}
+
+	return documentList, nil
+}
+
+// GetExtractionsWithoutReferences returns the successful document extractions whose references weren't extracted yet
+func GetExtractionsWithoutReferences(limit int) ([]*DocumentExtraction, error) {
+	query := "SELECT id, document_id, paper_id, error, page_count, version, extracted_at FROM " + documentExtractionsTable +
+		" WHERE error IS NULL AND references_extracted_at IS NULL ORDER BY id LIMIT $1"
+
+	var extractionList []*DocumentExtraction
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &extractionList, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the extractions without references: %w", err)
+	}
+
+	return extractionList, nil
+}
+
+// GetText returns the concatenated text of the extracted pages
+func (d *DocumentExtraction) GetText() (string, error) {
+	query := "SELECT page_number, text FROM " + documentPagesTable + " WHERE document_extraction_id = $1 ORDER BY page_number"
+
+	var pageList []*DocumentPage
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &pageList, query, d.Id)
+	if err != nil {
+		return "", fmt.Errorf("scanning the document pages: %w", err)
+	}
+
+	var textList []string
+	for _, page := range pageList {
+		textList = append(textList, page.Text)
+	}
+
+	return strings.Join(textList, "\n"), nil
+}
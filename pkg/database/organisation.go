@@ -7,11 +7,17 @@ import (
 	"github.com/jackc/pgx/v4"
 	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
+// Organisation is an affiliation as stated on the papers (i.e. raw text), possibly matched with a ROR organisation
 type Organisation struct {
 	Id   ID     `db:"id"`
 	Name string `db:"name"`
+
+	RorId          *string    `db:"ror_id"`
+	RorMatchMethod *string    `db:"ror_match_method"`
+	RorMatchedAt   *time.Time `db:"ror_matched_at"`
 }
 
 const organisationsTable = "organisations"
@@ -19,6 +25,9 @@ const organisationsTable = "organisations"
 var organisationsColumns = []string{
 	"id",
 	"name",
+	"ror_id",
+	"ror_match_method",
+	"ror_matched_at",
 }
 
 func saveOrganisationsTx(tx pgx.Tx, organisationList []*Organisation) error {
@@ -26,7 +35,7 @@ func saveOrganisationsTx(tx pgx.Tx, organisationList []*Organisation) error {
 
 	var organisationValues []interface{}
 	for _, organisation := range organisationList {
-		organisationValues = append(organisationValues, organisation.Name)
+		organisationValues = append(organisationValues, organisation.Name, organisation.RorId, organisation.RorMatchMethod, organisation.RorMatchedAt)
 	}
 
 	organisationPlaceholder := generateInsertPlaceholder(len(organisationsColumns[1:]), len(organisationList), 1)
@@ -90,3 +99,42 @@ func fetchAndUpdateOrganisationIdsTx(tx pgx.Tx, organisationList []*Organisation
 
 	return nil
 }
+
+// GetOrganisationsToMatch returns the organisations not matched against the ROR organisations yet, after the given id (for pagination)
+func GetOrganisationsToMatch(afterId ID, limit int) ([]*Organisation, error) {
+	query := "SELECT " + strings.Join(organisationsColumns, ", ") + " FROM " + organisationsTable +
+		" WHERE ror_matched_at IS NULL AND id > $1 ORDER BY id LIMIT $2"
+
+	var organisationList []*Organisation
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &organisationList, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the organisations to match: %w", err)
+	}
+
+	return organisationList, nil
+}
+
+// SaveRorMatch records the result of the ROR matching (a nil ROR id means no match, which isn't retried until ResetRorMatches)
+func (o *Organisation) SaveRorMatch(rorId *string, method *string) error {
+	now := time.Now()
+	query := "UPDATE " + organisationsTable + " SET ror_id = $1, ror_match_method = $2, ror_matched_at = $3 WHERE id = $4"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, rorId, method, now, o.Id)
+	if err != nil {
+		return fmt.Errorf("saving the ROR match of organisation %d: %w", o.Id, err)
+	}
+
+	o.RorId = rorId
+	o.RorMatchMethod = method
+	o.RorMatchedAt = &now
+	return nil
+}
+
+// ResetRorMatches marks the unmatched organisations as to match again (e.g. after loading a new ROR dump)
+func ResetRorMatches() (int64, error) {
+	query := "UPDATE " + organisationsTable + " SET ror_matched_at = NULL WHERE ror_id IS NULL AND ror_matched_at IS NOT NULL"
+	result, err := dbConnection.Pool.Exec(context.Background(), query)
+	if err != nil {
+		return 0, fmt.Errorf("resetting the unmatched organisations: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgx/v4"
+	"github.com/papetier/scraper/pkg/identifier"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
@@ -18,7 +20,25 @@ type Paper struct {
 	Title    string `db:"title"`
 	Year     *int   `db:"year"`
 
+	// normalised from the raw LaTeX of the title and abstract (see the papertext package)
+	DisplayTitle    string `db:"display_title"`
+	DisplayAbstract string `db:"display_abstract"`
+	SearchTitle     string `db:"search_title"`
+	SearchAbstract  string `db:"search_abstract"`
+
+	// parsed from the journal reference
+	VenueId *ID     `db:"venue_id"`
+	Volume  *string `db:"volume"`
+	Issue   *string `db:"issue"`
+	Pages   *string `db:"pages"`
+
+	// external identifiers (e.g. arXiv id, all the DOIs)
+	Identifiers []*PaperIdentifier
+	// code, dataset, project page and model links
+	Links []*PaperLink
+
 	Authors []*Author
+	Venue   *Venue
 }
 
 const (
@@ -33,6 +53,14 @@ var papersColumns = []string{
 	"abstract",
 	"title",
 	"year",
+	"venue_id",
+	"volume",
+	"issue",
+	"pages",
+	"display_title",
+	"display_abstract",
+	"search_title",
+	"search_abstract",
 }
 
 var papersAuthorsColumns = []string{
@@ -44,6 +72,14 @@ var papersAuthorsColumns = []string{
 func (p *Paper) SaveWithAuthorsTx(tx pgx.Tx) error {
 	log.Debug("saving the paper with its authors")
 
+	if p.Venue != nil {
+		err := p.Venue.saveWithPublisherTx(tx)
+		if err != nil {
+			return fmt.Errorf("saving the venue: %w", err)
+		}
+		p.VenueId = &p.Venue.Id
+	}
+
 	err := p.saveTx(tx)
 	if err != nil {
 		return fmt.Errorf("saving the paper: %w", err)
@@ -54,6 +90,16 @@ func (p *Paper) SaveWithAuthorsTx(tx pgx.Tx) error {
 		return fmt.Errorf("saving the papers_authors links: %w", err)
 	}
 
+	err = saveAuthorMentionsTx(tx, p)
+	if err != nil {
+		return fmt.Errorf("saving the author mentions: %w", err)
+	}
+
+	err = saveAffiliationsTx(tx, p)
+	if err != nil {
+		return fmt.Errorf("saving the affiliations: %w", err)
+	}
+
 	return nil
 }
 
@@ -63,7 +109,8 @@ func (p *Paper) saveTx(tx pgx.Tx) error {
 	paperPlaceholder := generateInsertPlaceholder(len(papersColumns[1:]), 1, 1)
 	papersQuery := "INSERT INTO " + papersTable + " (" + strings.Join(papersColumns[1:], ", ") + ") VALUES " + paperPlaceholder + " RETURNING id"
 
-	paperRow, err := tx.Query(context.Background(), papersQuery, p.Doi, p.JournalRef, p.Abstract, p.Title, p.Year)
+	paperRow, err := tx.Query(context.Background(), papersQuery, p.Doi, p.JournalRef, p.Abstract, p.Title, p.Year, p.VenueId, p.Volume, p.Issue, p.Pages,
+		p.DisplayTitle, p.DisplayAbstract, p.SearchTitle, p.SearchAbstract)
 	defer paperRow.Close()
 	if err != nil {
 		return fmt.Errorf("inserting the paper into the database: %w", err)
@@ -79,6 +126,69 @@ func (p *Paper) saveTx(tx pgx.Tx) error {
 	return nil
 }
 
+// updateTx updates the paper's fields from a newer version of a source record (the missing values are kept)
+func (p *Paper) updateTx(tx pgx.Tx) error {
+	log.Debugf("updating paper %d", p.Id)
+
+	if p.Venue != nil {
+		err := p.Venue.saveWithPublisherTx(tx)
+		if err != nil {
+			return fmt.Errorf("saving the venue: %w", err)
+		}
+		p.VenueId = &p.Venue.Id
+	}
+
+	query := "UPDATE " + papersTable + " SET doi = COALESCE($1, doi), journal_ref = COALESCE($2, journal_ref), abstract = $3, title = $4," +
+		" venue_id = COALESCE($5, venue_id), volume = COALESCE($6, volume), issue = COALESCE($7, issue), pages = COALESCE($8, pages)," +
+		" year = COALESCE($9, year), display_title = $10, display_abstract = $11, search_title = $12, search_abstract = $13 WHERE id = $14"
+	_, err := tx.Exec(context.Background(), query, p.Doi, p.JournalRef, p.Abstract, p.Title, p.VenueId, p.Volume, p.Issue, p.Pages,
+		p.Year, p.DisplayTitle, p.DisplayAbstract, p.SearchTitle, p.SearchAbstract, p.Id)
+	if err != nil {
+		return fmt.Errorf("updating the paper %d: %w", p.Id, err)
+	}
+
+	return nil
+}
+
+// GetPapersWithoutDisplayText returns the papers saved before their title and abstract were normalised
+func GetPapersWithoutDisplayText(limit int) ([]*Paper, error) {
+	query := "SELECT id, title, abstract FROM " + papersTable + " WHERE display_title IS NULL ORDER BY id LIMIT $1"
+
+	var paperList []*Paper
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &paperList, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the papers without display text: %w", err)
+	}
+
+	return paperList, nil
+}
+
+// SavePapersDisplayText saves the display and search versions of the papers' title and abstract
+func SavePapersDisplayText(paperList []*Paper) error {
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	query := "UPDATE " + papersTable + " SET display_title = $1, display_abstract = $2, search_title = $3, search_abstract = $4 WHERE id = $5"
+	for _, paper := range paperList {
+		_, err = tx.Exec(context.Background(), query, paper.DisplayTitle, paper.DisplayAbstract, paper.SearchTitle, paper.SearchAbstract, paper.Id)
+		if err != nil {
+			return fmt.Errorf("updating the display text of paper %d: %w", paper.Id, err)
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to save the papers' display text: %w", err)
+	}
+
+	return nil
+}
+
 func (p *Paper) saveAuthorsTx(tx pgx.Tx) error {
 	log.Debug("saving the papers_authors links")
 
@@ -98,3 +208,15 @@ func (p *Paper) saveAuthorsTx(tx pgx.Tx) error {
 
 	return nil
 }
+
+// FindPaperIdByDoi returns the id of the paper with the DOI, nil if none
+func FindPaperIdByDoi(doi string) (*ID, error) {
+	return FindPaperIdByIdentifier(identifier.Doi, doi)
+}
+
+// FindPaperIdBySearchTitle returns the id of the paper (not merged into another one) with the search version of the
+// title (see papertext.Search), nil if none
+func FindPaperIdBySearchTitle(searchTitle string) (*ID, error) {
+	query := "SELECT id FROM " + papersTable + " WHERE search_title = $1 AND merged_into_id IS NULL ORDER BY id LIMIT 1"
+	return findId(query, searchTitle)
+}
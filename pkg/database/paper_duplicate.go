@@ -0,0 +1,285 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// DedupPaper is what the deduplication compares: title, first author and year (and whether identifiers are shared)
+type DedupPaper struct {
+	Id                  ID      `db:"id"`
+	Title               string  `db:"title"`
+	SearchTitle         string  `db:"search_title"`
+	Year                *int    `db:"year"`
+	FirstAuthorBlockKey *string `db:"first_author_block_key"`
+
+	// set for the candidates only
+	SharesIdentifier bool `db:"shares_identifier"`
+}
+
+// PaperDuplicateCandidate is a possible duplicate waiting for review (low-confidence match)
+type PaperDuplicateCandidate struct {
+	Id ID `db:"id"`
+
+	Score   float64  `db:"score"`
+	Reasons []string `db:"reasons"`
+	Status  string   `db:"status"`
+
+	CreatedAt  time.Time  `db:"created_at"`
+	ReviewedAt *time.Time `db:"reviewed_at"`
+
+	PaperId          ID `db:"paper_id"`
+	CandidatePaperId ID `db:"candidate_paper_id"`
+}
+
+const paperDuplicateCandidatesTable = "paper_duplicate_candidates"
+
+const (
+	PendingDuplicateStatus  = "pending"
+	MergedDuplicateStatus   = "merged"
+	RejectedDuplicateStatus = "rejected"
+)
+
+var paperDuplicateCandidatesColumns = []string{
+	"id",
+	"paper_id",
+	"candidate_paper_id",
+	"score",
+	"reasons",
+	"status",
+	"created_at",
+	"reviewed_at",
+}
+
+// rootPaperQuery selects the paper the paper was (transitively) merged into, itself if it wasn't merged
+const rootPaperQuery = "WITH RECURSIVE chain AS (SELECT id, merged_into_id FROM " + papersTable + " WHERE id = $1" +
+	" UNION SELECT p.id, p.merged_into_id FROM " + papersTable + " p JOIN chain c ON p.id = c.merged_into_id)" +
+	" SELECT id FROM chain WHERE merged_into_id IS NULL LIMIT 1"
+
+// dedupPaperSelect selects the DedupPaper fields of the papers `p` (the year falls back to the arXiv publication year,
+// the search title is empty for the papers not normalised yet)
+const dedupPaperSelect = "SELECT p.id, p.title, COALESCE(p.search_title, '') AS search_title," +
+	" COALESCE(p.year, (SELECT extract(year FROM min(e.published_at))::int FROM " + arxivEprintsTable + " e WHERE e.paper_id = p.id)) AS year," +
+	" (SELECT m.block_key FROM " + authorMentionsTable + " m WHERE m.paper_id = p.id ORDER BY m.author_order LIMIT 1) AS first_author_block_key"
+
+// GetPapersToDeduplicate returns the papers not checked for duplicates yet, after the given id (for pagination)
+func GetPapersToDeduplicate(afterId ID, limit int) ([]*DedupPaper, error) {
+	query := dedupPaperSelect + ", false AS shares_identifier FROM " + papersTable + " p" +
+		" WHERE p.dedup_checked_at IS NULL AND p.merged_into_id IS NULL AND p.id > $1 ORDER BY p.id LIMIT $2"
+
+	var paperList []*DedupPaper
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &paperList, query, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the papers to deduplicate: %w", err)
+	}
+
+	return paperList, nil
+}
+
+// GetDuplicateCandidates returns the other papers which may be duplicates of the paper:
+// sharing an identifier (e.g. DOI), having the same search title, or the same first author (initial + family name)
+// within a year
+// The candidates are first selected on these (indexed) keys, the compared fields are only computed for them
+func GetDuplicateCandidates(paper *DedupPaper, limit int) ([]*DedupPaper, error) {
+	candidateIds := "SELECT i2.paper_id AS id, true AS shares_identifier FROM " + paperIdentifiersTable + " i1" +
+		" JOIN " + paperIdentifiersTable + " i2 ON i2.scheme = i1.scheme AND i2.value = i1.value WHERE i1.paper_id = $1" +
+		" UNION ALL SELECT p1.id, true FROM " + papersTable + " p1 WHERE p1.doi = (SELECT doi FROM " + papersTable + " WHERE id = $1)" +
+		" UNION ALL SELECT p2.id, false FROM " + papersTable + " p2 WHERE $2 <> '' AND p2.search_title = $2" +
+		" UNION ALL SELECT m.paper_id, false FROM " + authorMentionsTable + " m WHERE m.block_key = $3 AND m.author_order = 0"
+
+	query := "SELECT c.id, c.title, c.search_title, c.year, c.first_author_block_key, c.shares_identifier FROM (" +
+		dedupPaperSelect + ", bool_or(k.shares_identifier) AS shares_identifier FROM (" + candidateIds + ") k" +
+		" JOIN " + papersTable + " p ON p.id = k.id WHERE p.id <> $1 AND p.merged_into_id IS NULL GROUP BY p.id) c" +
+		" WHERE c.shares_identifier" +
+		" OR ($2 <> '' AND c.search_title = $2)" +
+		" OR (c.first_author_block_key = $3 AND (c.year IS NULL OR $4::int IS NULL OR abs(c.year - $4::int) <= 1))" +
+		// the strongest keys first, so that the first author's other papers don't push them past the limit
+		" ORDER BY c.shares_identifier DESC, ($2 <> '' AND c.search_title = $2) DESC, c.id LIMIT $5"
+
+	var candidateList []*DedupPaper
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &candidateList, query, paper.Id, paper.SearchTitle, paper.FirstAuthorBlockKey, paper.Year, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the duplicate candidates of paper %d: %w", paper.Id, err)
+	}
+
+	return candidateList, nil
+}
+
+// GetPaperMergedIntoId returns the id of the paper the paper was merged into, nil if it wasn't merged
+func GetPaperMergedIntoId(paperId ID) (*ID, error) {
+	query := "SELECT merged_into_id FROM " + papersTable + " WHERE id = $1 AND merged_into_id IS NOT NULL"
+	return findId(query, paperId)
+}
+
+// GetRootPaperId returns the id of the paper the paper was (transitively) merged into, its own id if it wasn't merged
+func GetRootPaperId(paperId ID) (ID, error) {
+	rootId, err := findId(rootPaperQuery, paperId)
+	if err != nil {
+		return 0, fmt.Errorf("fetching the root paper of paper %d: %w", paperId, err)
+	}
+	if rootId == nil {
+		return 0, fmt.Errorf("no root paper found for paper %d", paperId)
+	}
+	return *rootId, nil
+}
+
+func getRootPaperIdTx(tx pgx.Tx, paperId ID) (ID, error) {
+	var rootId ID
+	err := tx.QueryRow(context.Background(), rootPaperQuery, paperId).Scan(&rootId)
+	if err != nil {
+		return 0, fmt.Errorf("fetching the root paper of paper %d: %w", paperId, err)
+	}
+	return rootId, nil
+}
+
+// MarkPaperDeduplicated records that the paper was checked for duplicates
+func MarkPaperDeduplicated(paperId ID) error {
+	query := "UPDATE " + papersTable + " SET dedup_checked_at = now() WHERE id = $1"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, paperId)
+	if err != nil {
+		return fmt.Errorf("marking the paper %d as deduplicated: %w", paperId, err)
+	}
+	return nil
+}
+
+// Save adds the candidate to the review queue (once per pair of papers)
+func (c *PaperDuplicateCandidate) Save() error {
+	c.Status = PendingDuplicateStatus
+	c.CreatedAt = time.Now()
+
+	placeholder := generateInsertPlaceholder(len(paperDuplicateCandidatesColumns[1:]), 1, 1)
+	query := "INSERT INTO " + paperDuplicateCandidatesTable + " (" + strings.Join(paperDuplicateCandidatesColumns[1:], ", ") + ") VALUES " + placeholder + " ON CONFLICT DO NOTHING"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, c.PaperId, c.CandidatePaperId, c.Score, c.Reasons, c.Status, c.CreatedAt, c.ReviewedAt)
+	if err != nil {
+		return fmt.Errorf("saving the duplicate candidate %d/%d: %w", c.PaperId, c.CandidatePaperId, err)
+	}
+
+	return nil
+}
+
+// GetPendingDuplicateCandidates returns the review queue (highest scores first)
+func GetPendingDuplicateCandidates(limit int) ([]*PaperDuplicateCandidate, error) {
+	query := "SELECT " + strings.Join(paperDuplicateCandidatesColumns, ", ") + " FROM " + paperDuplicateCandidatesTable +
+		" WHERE status = $1 ORDER BY score DESC, id LIMIT $2"
+
+	var candidateList []*PaperDuplicateCandidate
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &candidateList, query, PendingDuplicateStatus, limit)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the pending duplicate candidates: %w", err)
+	}
+
+	return candidateList, nil
+}
+
+// ReviewDuplicateCandidate merges (if accepted) the papers of a pending candidate and records the decision
+func ReviewDuplicateCandidate(candidateId ID, isAccepted bool) error {
+	var candidate PaperDuplicateCandidate
+	query := "SELECT " + strings.Join(paperDuplicateCandidatesColumns, ", ") + " FROM " + paperDuplicateCandidatesTable + " WHERE id = $1"
+	err := pgxscan.Get(context.Background(), dbConnection.Pool, &candidate, query, candidateId)
+	if err != nil {
+		return fmt.Errorf("fetching the duplicate candidate %d: %w", candidateId, err)
+	}
+	if candidate.Status != PendingDuplicateStatus {
+		return fmt.Errorf("the duplicate candidate %d was already reviewed (%s)", candidateId, candidate.Status)
+	}
+
+	status := RejectedDuplicateStatus
+	if isAccepted {
+		// either paper may have been merged since the candidate was queued: the papers they were merged into are merged
+		canonicalId, err := GetRootPaperId(candidate.CandidatePaperId)
+		if err != nil {
+			return err
+		}
+		duplicateId, err := GetRootPaperId(candidate.PaperId)
+		if err != nil {
+			return err
+		}
+
+		if canonicalId == duplicateId {
+			log.Warnf("the papers of the duplicate candidate %d were already merged into paper %d, rejecting it", candidateId, canonicalId)
+		} else {
+			status = MergedDuplicateStatus
+			err = MergePapers(canonicalId, duplicateId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	updateQuery := "UPDATE " + paperDuplicateCandidatesTable + " SET status = $1, reviewed_at = now() WHERE id = $2"
+	_, err = dbConnection.Pool.Exec(context.Background(), updateQuery, status, candidateId)
+	if err != nil {
+		return fmt.Errorf("updating the status of the duplicate candidate %d: %w", candidateId, err)
+	}
+
+	return nil
+}
+
+// MergePapers merges the duplicate paper into the canonical one: the source records (e.g. arXiv eprints), documents,
+// identifiers, links, citations and authorships are moved to the canonical paper, and the duplicate points to it
+// The papers already merged are replaced by the ones they were merged into (the oldest one staying the canonical one),
+// the papers already merged into the same paper are rejected
+func MergePapers(canonicalPaperId ID, duplicatePaperId ID) error {
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	canonicalRootId, err := getRootPaperIdTx(tx, canonicalPaperId)
+	if err != nil {
+		return err
+	}
+	duplicateRootId, err := getRootPaperIdTx(tx, duplicatePaperId)
+	if err != nil {
+		return err
+	}
+	if canonicalRootId == duplicateRootId {
+		return fmt.Errorf("papers %d and %d were already merged into paper %d", duplicatePaperId, canonicalPaperId, canonicalRootId)
+	}
+	canonicalPaperId, duplicatePaperId = canonicalRootId, duplicateRootId
+	if duplicatePaperId < canonicalPaperId {
+		canonicalPaperId, duplicatePaperId = duplicatePaperId, canonicalPaperId
+	}
+	log.Infof("merging paper %d into paper %d", duplicatePaperId, canonicalPaperId)
+
+	queryList := []string{
+		"UPDATE " + arxivEprintsTable + " SET paper_id = $1 WHERE paper_id = $2",
+		"UPDATE " + documentsTable + " SET paper_id = $1 WHERE paper_id = $2",
+		"UPDATE " + documentExtractionsTable + " SET paper_id = $1 WHERE paper_id = $2",
+		"UPDATE " + paperIdentifiersTable + " SET paper_id = $1 WHERE paper_id = $2",
+		// the links (unique per paper and URL) already on the canonical paper are dropped
+		"UPDATE " + paperLinksTable + " SET paper_id = $1 WHERE paper_id = $2 AND url NOT IN (SELECT url FROM " + paperLinksTable + " WHERE paper_id = $1)",
+		"DELETE FROM " + paperLinksTable + " WHERE paper_id = $2",
+		"UPDATE " + citationsTable + " SET citing_paper_id = $1 WHERE citing_paper_id = $2",
+		"UPDATE " + citationsTable + " SET cited_paper_id = $1 WHERE cited_paper_id = $2",
+		// the canonical paper keeps its authorships, the duplicate's ones are only moved if it has none
+		"UPDATE " + papersAuthorsTable + " SET paper_id = $1 WHERE paper_id = $2 AND NOT EXISTS (SELECT 1 FROM " + papersAuthorsTable + " WHERE paper_id = $1)",
+		"UPDATE " + authorMentionsTable + " SET paper_id = $1 WHERE paper_id = $2 AND NOT EXISTS (SELECT 1 FROM " + authorMentionsTable + " WHERE paper_id = $1)",
+		"UPDATE " + affiliationsTable + " SET paper_id = $1 WHERE paper_id = $2 AND NOT EXISTS (SELECT 1 FROM " + affiliationsTable + " WHERE paper_id = $1)",
+		"UPDATE " + papersTable + " SET merged_into_id = $1 WHERE id = $2 OR merged_into_id = $2",
+		"UPDATE " + paperDuplicateCandidatesTable + " SET status = '" + MergedDuplicateStatus + "', reviewed_at = now()" +
+			" WHERE status = '" + PendingDuplicateStatus + "' AND ((paper_id = $1 AND candidate_paper_id = $2) OR (paper_id = $2 AND candidate_paper_id = $1))",
+	}
+
+	for _, query := range queryList {
+		_, err = tx.Exec(context.Background(), query, canonicalPaperId, duplicatePaperId)
+		if err != nil {
+			return fmt.Errorf("merging paper %d into paper %d: %w", duplicatePaperId, canonicalPaperId, err)
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to merge paper %d into paper %d: %w", duplicatePaperId, canonicalPaperId, err)
+	}
+
+	return nil
+}
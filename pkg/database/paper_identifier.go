@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/papetier/scraper/pkg/doi"
+	"github.com/papetier/scraper/pkg/identifier"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// PaperIdentifier is an external identifier of a paper (e.g. arXiv id, DOI, PMID), unique per scheme (see the identifier package)
+// The values are normalised (see identifier.Normalise), the source is the website which first stated it
+type PaperIdentifier struct {
+	Scheme      string    `db:"scheme"`
+	Value       string    `db:"value"`
+	Source      string    `db:"source"`
+	FirstSeenAt time.Time `db:"first_seen_at"`
+
+	PaperId ID `db:"paper_id"`
+}
+
+const paperIdentifiersTable = "paper_identifiers"
+
+const (
+	// the papers saved before the identifiers were all scraped from arXiv
+	legacyIdentifierSource = "arxiv"
+	// the papers backfilled per batch by BackfillPaperIdentifiers
+	backfillBatchSize = 1000
+)
+
+// legacyPaperIdentifiers are the identifiers of a paper saved before the identifiers: its DOI (stored verbatim, possibly
+// several) and the arXiv ids of its eprints
+type legacyPaperIdentifiers struct {
+	Id       ID       `db:"id"`
+	Doi      *string  `db:"doi"`
+	ArxivIds []string `db:"arxiv_ids"`
+}
+
+var paperIdentifiersColumns = []string{
+	"paper_id",
+	"scheme",
+	"value",
+	"source",
+	"first_seen_at",
+}
+
+// NewPaperIdentifier returns the normalised identifier, or an error if its value isn't valid for the scheme
+func NewPaperIdentifier(scheme string, value string, source string) (*PaperIdentifier, error) {
+	normalisedValue, err := identifier.Normalise(scheme, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaperIdentifier{
+		Scheme:      scheme,
+		Value:       normalisedValue,
+		Source:      source,
+		FirstSeenAt: time.Now(),
+	}, nil
+}
+
+// AddIdentifier adds the identifier to the paper, ignoring the invalid ones (with a warning)
+func (p *Paper) AddIdentifier(scheme string, value string, source string) {
+	paperIdentifier, err := NewPaperIdentifier(scheme, value, source)
+	if err != nil {
+		log.Warnf("ignoring the identifier of paper `%s`: %s", p.Title, err)
+		return
+	}
+	for _, existingIdentifier := range p.Identifiers {
+		if existingIdentifier.Scheme == paperIdentifier.Scheme && existingIdentifier.Value == paperIdentifier.Value {
+			return
+		}
+	}
+	p.Identifiers = append(p.Identifiers, paperIdentifier)
+}
+
+// SavePaperIdentifier links an identifier to an existing paper (the first paper stating an identifier keeps it)
+func SavePaperIdentifier(paperId ID, paperIdentifier *PaperIdentifier) error {
+	query := "INSERT INTO " + paperIdentifiersTable + " (" + strings.Join(paperIdentifiersColumns, ", ") + ") VALUES " +
+		generateInsertPlaceholder(len(paperIdentifiersColumns), 1, 1) + " ON CONFLICT DO NOTHING"
+	_, err := dbConnection.Pool.Exec(context.Background(), query, paperId, paperIdentifier.Scheme, paperIdentifier.Value, paperIdentifier.Source, paperIdentifier.FirstSeenAt)
+	if err != nil {
+		return fmt.Errorf("inserting the %s identifier %s of paper %d: %w", paperIdentifier.Scheme, paperIdentifier.Value, paperId, err)
+	}
+
+	paperIdentifier.PaperId = paperId
+	return nil
+}
+
+// FindPaperIdByIdentifier returns the id of the paper with the identifier (normalised first), nil if none or invalid
+func FindPaperIdByIdentifier(scheme string, value string) (*ID, error) {
+	normalisedValue, err := identifier.Normalise(scheme, value)
+	if err != nil {
+		return nil, nil
+	}
+
+	query := "SELECT paper_id FROM " + paperIdentifiersTable + " WHERE scheme = $1 AND value = $2"
+	return findId(query, scheme, normalisedValue)
+}
+
+// FindPaperIdByIdentifiers returns the id of the paper with any of the identifiers, nil if none
+// Providers can use it to check whether a paper is already known before inserting it
+func FindPaperIdByIdentifiers(identifierList []*PaperIdentifier) (*ID, error) {
+	schemeList, valueList := splitIdentifiers(identifierList)
+	if len(schemeList) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT paper_id FROM " + paperIdentifiersTable + " WHERE (scheme, value) IN (SELECT * FROM unnest($1::text[], $2::text[])) ORDER BY paper_id LIMIT 1"
+	return findId(query, schemeList, valueList)
+}
+
+// GetPaperIdentifiers returns the identifiers of the paper
+func GetPaperIdentifiers(paperId ID) ([]*PaperIdentifier, error) {
+	query := "SELECT " + strings.Join(paperIdentifiersColumns, ", ") + " FROM " + paperIdentifiersTable + " WHERE paper_id = $1 ORDER BY scheme, first_seen_at"
+
+	var identifierList []*PaperIdentifier
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &identifierList, query, paperId)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the identifiers of paper %d: %w", paperId, err)
+	}
+
+	return identifierList, nil
+}
+
+// BackfillPaperIdentifiers saves the identifiers of the papers saved before the identifiers (i.e. without any), from
+// their DOI and the arXiv ids of their eprints, and returns the backfilled papers count
+func BackfillPaperIdentifiers() (int, error) {
+	query := "SELECT p.id, p.doi, array_remove(array_agg(e.arxiv_id), NULL) AS arxiv_ids FROM " + papersTable + " p" +
+		" LEFT JOIN " + arxivEprintsTable + " e ON e.paper_id = p.id" +
+		" WHERE p.id > $1 AND NOT EXISTS (SELECT 1 FROM " + paperIdentifiersTable + " i WHERE i.paper_id = p.id)" +
+		" GROUP BY p.id ORDER BY p.id LIMIT $2"
+
+	backfilledCount := 0
+	var lastId ID
+	for {
+		var legacyList []*legacyPaperIdentifiers
+		err := pgxscan.Select(context.Background(), dbConnection.Pool, &legacyList, query, lastId, backfillBatchSize)
+		if err != nil {
+			return backfilledCount, fmt.Errorf("scanning the papers without identifiers: %w", err)
+		}
+		if len(legacyList) == 0 {
+			break
+		}
+		lastId = legacyList[len(legacyList)-1].Id
+
+		count, err := backfillPaperIdentifiers(legacyList)
+		if err != nil {
+			return backfilledCount, err
+		}
+		backfilledCount += count
+		log.Infof("%d papers backfilled with their identifiers", backfilledCount)
+	}
+
+	return backfilledCount, nil
+}
+
+func backfillPaperIdentifiers(legacyList []*legacyPaperIdentifiers) (int, error) {
+	// prepare transaction
+	tx, err := dbConnection.Pool.Begin(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+
+	backfilledCount := 0
+	for _, legacy := range legacyList {
+		paper := &Paper{Id: legacy.Id}
+		for _, arxivId := range legacy.ArxivIds {
+			paper.AddIdentifier(identifier.Arxiv, arxivId, legacyIdentifierSource)
+		}
+		if legacy.Doi != nil {
+			for _, paperDoi := range doi.Parse(*legacy.Doi) {
+				paper.AddIdentifier(identifier.Doi, paperDoi, legacyIdentifierSource)
+			}
+		}
+		if len(paper.Identifiers) == 0 {
+			continue
+		}
+
+		err = paper.saveIdentifiersTx(tx)
+		if err != nil {
+			return 0, fmt.Errorf("backfilling the identifiers of paper %d: %w", legacy.Id, err)
+		}
+		backfilledCount++
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("committing the transaction to backfill the paper identifiers: %w", err)
+	}
+
+	return backfilledCount, nil
+}
+
+// saveIdentifiersTx saves the paper's identifiers, the ones already known (e.g. for another paper) are left as is
+func (p *Paper) saveIdentifiersTx(tx pgx.Tx) error {
+	if len(p.Identifiers) == 0 {
+		return nil
+	}
+	log.Debug("saving the paper identifiers")
+
+	var identifierValues []interface{}
+	for _, paperIdentifier := range p.Identifiers {
+		paperIdentifier.PaperId = p.Id
+		identifierValues = append(identifierValues, paperIdentifier.PaperId, paperIdentifier.Scheme, paperIdentifier.Value, paperIdentifier.Source, paperIdentifier.FirstSeenAt)
+	}
+
+	identifierPlaceholder := generateInsertPlaceholder(len(paperIdentifiersColumns), len(p.Identifiers), 1)
+	identifiersQuery := "INSERT INTO " + paperIdentifiersTable + " (" + strings.Join(paperIdentifiersColumns, ", ") + ") VALUES " + identifierPlaceholder + " ON CONFLICT DO NOTHING"
+
+	_, err := tx.Exec(context.Background(), identifiersQuery, identifierValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the paper identifiers into the database: %w", err)
+	}
+
+	return nil
+}
+
+// findPaperIdByIdentifiersTx returns the id of the paper having one of the identifiers, nil if none
+func findPaperIdByIdentifiersTx(tx pgx.Tx, identifierList []*PaperIdentifier) (*ID, error) {
+	schemeList, valueList := splitIdentifiers(identifierList)
+	if len(schemeList) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT paper_id FROM " + paperIdentifiersTable + " WHERE (scheme, value) IN (SELECT * FROM unnest($1::text[], $2::text[])) ORDER BY paper_id LIMIT 1"
+	rows, err := tx.Query(context.Background(), query, schemeList, valueList)
+	defer rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("querying the paper identifiers: %w", err)
+	}
+
+	var id *ID
+	for rows.Next() {
+		id = new(ID)
+		err = rows.Scan(id)
+		if err != nil {
+			return nil, fmt.Errorf("scanning the paper id: %w", err)
+		}
+	}
+
+	return id, nil
+}
+
+func splitIdentifiers(identifierList []*PaperIdentifier) ([]string, []string) {
+	var schemeList, valueList []string
+	for _, paperIdentifier := range identifierList {
+		schemeList = append(schemeList, paperIdentifier.Scheme)
+		valueList = append(valueList, paperIdentifier.Value)
+	}
+	return schemeList, valueList
+}
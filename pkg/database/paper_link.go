@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// PaperLink is a link to the code, dataset, project page or model of a paper (see the paperlink package), found in the
+// text of the paper (e.g. its abstract) or of its eprint (e.g. the arXiv's comment)
+type PaperLink struct {
+	Url    string `db:"url"`
+	Host   string `db:"host"`
+	Kind   string `db:"kind"`
+	Source string `db:"source"`
+
+	FirstSeenAt time.Time `db:"first_seen_at"`
+
+	PaperId ID `db:"paper_id"`
+}
+
+// the kinds of paper links
+const (
+	CodeLinkKind        = "code"
+	DatasetLinkKind     = "dataset"
+	ProjectPageLinkKind = "project_page"
+	ModelLinkKind       = "model"
+)
+
+// the sources of paper links
+const (
+	AbstractLinkSource     = "abstract"
+	ArxivCommentLinkSource = "arxiv_comment"
+)
+
+const paperLinksTable = "paper_links"
+
+var paperLinksColumns = []string{
+	"paper_id",
+	"url",
+	"host",
+	"kind",
+	"source",
+	"first_seen_at",
+}
+
+// AddLink adds the link to the paper, unless its URL is already there
+func (p *Paper) AddLink(paperLink *PaperLink) {
+	for _, existingLink := range p.Links {
+		if existingLink.Url == paperLink.Url {
+			return
+		}
+	}
+	p.Links = append(p.Links, paperLink)
+}
+
+// GetPaperLinksByHost returns the links of a host (e.g. `github.com`, `github.io` for all the GitHub pages), of a kind if any
+func GetPaperLinksByHost(host string, kind *string) ([]*PaperLink, error) {
+	query := "SELECT " + strings.Join(paperLinksColumns, ", ") + " FROM " + paperLinksTable +
+		" WHERE (host = $1 OR host LIKE '%.' || $1) AND ($2::text IS NULL OR kind = $2) ORDER BY paper_id, url"
+
+	var linkList []*PaperLink
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &linkList, query, strings.ToLower(host), kind)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the paper links of host %s: %w", host, err)
+	}
+
+	return linkList, nil
+}
+
+// saveLinksTx saves the paper's links, the ones already known are left as is
+func (p *Paper) saveLinksTx(tx pgx.Tx) error {
+	if len(p.Links) == 0 {
+		return nil
+	}
+	log.Debug("saving the paper links")
+
+	var linkValues []interface{}
+	for _, paperLink := range p.Links {
+		paperLink.PaperId = p.Id
+		linkValues = append(linkValues, paperLink.PaperId, paperLink.Url, paperLink.Host, paperLink.Kind, paperLink.Source, paperLink.FirstSeenAt)
+	}
+
+	linkPlaceholder := generateInsertPlaceholder(len(paperLinksColumns), len(p.Links), 1)
+	linksQuery := "INSERT INTO " + paperLinksTable + " (" + strings.Join(paperLinksColumns, ", ") + ") VALUES " + linkPlaceholder + " ON CONFLICT DO NOTHING"
+
+	_, err := tx.Exec(context.Background(), linksQuery, linkValues...)
+	if err != nil {
+		return fmt.Errorf("inserting the paper links into the database: %w", err)
+	}
+
+	return nil
+}
@@ -1,7 +1,49 @@
 package database
 
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
 type Publisher struct {
 	Id   ID     `db:"id"`
 	Name string `db:"name"`
 	Url  string `db:"url"`
 }
+
+const publishersTable = "publishers"
+
+var publishersColumns = []string{
+	"id",
+	"name",
+	"url",
+}
+
+// saveTx saves the publisher, or fetches its id if it already exists (by name)
+func (p *Publisher) saveTx(tx pgx.Tx) error {
+	publisherPlaceholder := generateInsertPlaceholder(len(publishersColumns[1:]), 1, 1)
+	publishersQuery := "INSERT INTO " + publishersTable + " (" + strings.Join(publishersColumns[1:], ", ") + ") VALUES " + publisherPlaceholder + " ON CONFLICT DO NOTHING RETURNING id"
+	publisherRows, err := tx.Query(context.Background(), publishersQuery, p.Name, p.Url)
+	if err != nil {
+		return fmt.Errorf("inserting the publisher into the database: %w", err)
+	}
+	for publisherRows.Next() {
+		err = publisherRows.Scan(&p.Id)
+		if err != nil {
+			publisherRows.Close()
+			return fmt.Errorf("scanning the publisher id: %w", err)
+		}
+	}
+	publisherRows.Close()
+
+	if p.Id == 0 {
+		err = tx.QueryRow(context.Background(), "SELECT id FROM "+publishersTable+" WHERE name = $1", p.Name).Scan(&p.Id)
+		if err != nil {
+			return fmt.Errorf("fetching the publisher id: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/pgxscan"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// RorOrganisation is an organisation of the Research Organization Registry (https://ror.org), loaded from a data dump
+type RorOrganisation struct {
+	RorId string `db:"ror_id"`
+
+	Name        string   `db:"name"`
+	Status      string   `db:"status"`
+	Types       []string `db:"types"`
+	Aliases     []string `db:"aliases"`
+	Acronyms    []string `db:"acronyms"`
+	Labels      []string `db:"labels"`
+	CountryCode *string  `db:"country_code"`
+	CountryName *string  `db:"country_name"`
+
+	LoadedAt time.Time `db:"loaded_at"`
+}
+
+const rorOrganisationsTable = "ror_organisations"
+
+var rorOrganisationsColumns = []string{
+	"ror_id",
+	"name",
+	"status",
+	"types",
+	"aliases",
+	"acronyms",
+	"labels",
+	"country_code",
+	"country_name",
+	"loaded_at",
+}
+
+// SaveRorOrganisations upserts a batch of ROR organisations (keyed by ROR id)
+func SaveRorOrganisations(rorOrganisationList []*RorOrganisation) error {
+	if len(rorOrganisationList) == 0 {
+		return nil
+	}
+	log.Debugf("saving %d ROR organisations", len(rorOrganisationList))
+
+	var values []interface{}
+	for _, rorOrganisation := range rorOrganisationList {
+		values = append(values, rorOrganisation.RorId, rorOrganisation.Name, rorOrganisation.Status, rorOrganisation.Types, rorOrganisation.Aliases, rorOrganisation.Acronyms, rorOrganisation.Labels, rorOrganisation.CountryCode, rorOrganisation.CountryName, rorOrganisation.LoadedAt)
+	}
+
+	var updateList []string
+	for _, column := range rorOrganisationsColumns[1:] {
+		updateList = append(updateList, column+" = EXCLUDED."+column)
+	}
+
+	placeholder := generateInsertPlaceholder(len(rorOrganisationsColumns), len(rorOrganisationList), 1)
+	query := "INSERT INTO " + rorOrganisationsTable + " (" + strings.Join(rorOrganisationsColumns, ", ") + ") VALUES " + placeholder +
+		" ON CONFLICT (ror_id) DO UPDATE SET " + strings.Join(updateList, ", ")
+
+	_, err := dbConnection.Pool.Exec(context.Background(), query, values...)
+	if err != nil {
+		return fmt.Errorf("upserting the ROR organisations: %w", err)
+	}
+
+	return nil
+}
+
+// GetRorOrganisations returns all the loaded ROR organisations
+func GetRorOrganisations() ([]*RorOrganisation, error) {
+	query := "SELECT " + strings.Join(rorOrganisationsColumns, ", ") + " FROM " + rorOrganisationsTable
+
+	var rorOrganisationList []*RorOrganisation
+	err := pgxscan.Select(context.Background(), dbConnection.Pool, &rorOrganisationList, query)
+	if err != nil {
+		return nil, fmt.Errorf("scanning the ROR organisations: %w", err)
+	}
+
+	return rorOrganisationList, nil
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -36,3 +38,22 @@ func generateTupleFilterPlaceholder(columnList []string, tupleCount int) string
 
 	return strings.Join(orFilterList, " OR ")
 }
+
+func findId(query string, parameters ...interface{}) (*ID, error) {
+	rows, err := dbConnection.Pool.Query(context.Background(), query, parameters...)
+	defer rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("querying the id: %w", err)
+	}
+
+	var id *ID
+	for rows.Next() {
+		id = new(ID)
+		err = rows.Scan(id)
+		if err != nil {
+			return nil, fmt.Errorf("scanning the id: %w", err)
+		}
+	}
+
+	return id, nil
+}
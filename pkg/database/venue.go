@@ -1,6 +1,65 @@
 package database
 
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
 type Venue struct {
-	Id   ID     `db:"id"`
-	Name string `db:"name"`
+	Id           ID      `db:"id"`
+	Name         string  `db:"name"`
+	Abbreviation *string `db:"abbreviation"`
+
+	PublisherId *ID `db:"publisher_id"`
+
+	Publisher *Publisher
+}
+
+const venuesTable = "venues"
+
+var venuesColumns = []string{
+	"id",
+	"name",
+	"abbreviation",
+	"publisher_id",
+}
+
+// saveWithPublisherTx saves the venue (and its publisher), or fetches its id if it already exists (by name)
+func (v *Venue) saveWithPublisherTx(tx pgx.Tx) error {
+	log.Debugf("saving venue %s", v.Name)
+
+	if v.Publisher != nil {
+		err := v.Publisher.saveTx(tx)
+		if err != nil {
+			return fmt.Errorf("saving the publisher: %w", err)
+		}
+		v.PublisherId = &v.Publisher.Id
+	}
+
+	venuePlaceholder := generateInsertPlaceholder(len(venuesColumns[1:]), 1, 1)
+	venuesQuery := "INSERT INTO " + venuesTable + " (" + strings.Join(venuesColumns[1:], ", ") + ") VALUES " + venuePlaceholder + " ON CONFLICT DO NOTHING RETURNING id"
+	venueRows, err := tx.Query(context.Background(), venuesQuery, v.Name, v.Abbreviation, v.PublisherId)
+	if err != nil {
+		return fmt.Errorf("inserting the venue into the database: %w", err)
+	}
+	for venueRows.Next() {
+		err = venueRows.Scan(&v.Id)
+		if err != nil {
+			venueRows.Close()
+			return fmt.Errorf("scanning the venue id: %w", err)
+		}
+	}
+	venueRows.Close()
+
+	if v.Id == 0 {
+		err = tx.QueryRow(context.Background(), "SELECT id FROM "+venuesTable+" WHERE name = $1", v.Name).Scan(&v.Id)
+		if err != nil {
+			return fmt.Errorf("fetching the venue id: %w", err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,137 @@
+package dedup
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/personname"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// the confidence above which duplicates are merged automatically, and above which they're queued for review
+const (
+	mergeThreshold  = 0.9
+	reviewThreshold = 0.6
+)
+
+// match weights (the title similarity is weighted, the rest are bonuses/penalties)
+// The similarity is raised to titleExponent so that only near-identical titles get most of the weight
+const (
+	titleWeight          = 0.7
+	titleExponent        = 4
+	numberingPenalty     = 0.3
+	firstAuthorScore     = 0.2
+	sameYearScore        = 0.1
+	closeYearScore       = 0.05
+	distantYearPenalty   = 0.3
+	identifierMatchScore = 1.0
+)
+
+var numberingRegexp = regexp.MustCompile(`^(?:\d+|[ivx]+)$`)
+
+const (
+	identifierReason  = "shared identifier"
+	titleReason       = "similar title"
+	firstAuthorReason = "same first author"
+	yearReason        = "same year"
+)
+
+// score returns the confidence (0-1) that the candidate is a duplicate of the paper, with the reasons
+// Sharing an identifier (e.g. DOI) is conclusive, otherwise the title similarity, first author and year are combined
+func score(paper *database.DedupPaper, candidate *database.DedupPaper) (float64, []string) {
+	if candidate.SharesIdentifier {
+		return identifierMatchScore, []string{identifierReason}
+	}
+
+	var reasonList []string
+	similarity := titleSimilarity(paper.Title, candidate.Title)
+	total := math.Pow(similarity, titleExponent) * titleWeight
+	if similarity >= 0.8 {
+		reasonList = append(reasonList, titleReason)
+	}
+	// e.g. `Part I` vs `Part II`
+	if numbering(paper.Title) != numbering(candidate.Title) {
+		total -= numberingPenalty
+	}
+
+	if paper.FirstAuthorBlockKey != nil && candidate.FirstAuthorBlockKey != nil && *paper.FirstAuthorBlockKey == *candidate.FirstAuthorBlockKey {
+		total += firstAuthorScore
+		reasonList = append(reasonList, firstAuthorReason)
+	}
+
+	if paper.Year != nil && candidate.Year != nil {
+		switch yearDifference := *paper.Year - *candidate.Year; {
+		case yearDifference == 0:
+			total += sameYearScore
+			reasonList = append(reasonList, yearReason)
+		case yearDifference >= -1 && yearDifference <= 1:
+			// e.g. preprint vs journal version
+			total += closeYearScore
+		default:
+			total -= distantYearPenalty
+		}
+	}
+
+	if total > 1 {
+		total = 1
+	}
+	return total, reasonList
+}
+
+// titleSimilarity is the Dice coefficient of the normalised titles' character bigrams (robust to small edits)
+func titleSimilarity(firstTitle, secondTitle string) float64 {
+	firstBigrams := bigrams(personname.Key(firstTitle))
+	secondBigrams := bigrams(personname.Key(secondTitle))
+	if len(firstBigrams) == 0 || len(secondBigrams) == 0 {
+		return 0
+	}
+
+	sharedCount := 0
+	for bigram, count := range firstBigrams {
+		if secondCount, exists := secondBigrams[bigram]; exists {
+			sharedCount += minInt(count, secondCount)
+		}
+	}
+
+	return 2 * float64(sharedCount) / float64(countBigrams(firstBigrams)+countBigrams(secondBigrams))
+}
+
+// numbering returns the numbers and roman numerals of a title (e.g. `part ii 2`)
+func numbering(title string) string {
+	var numberList []string
+	for _, word := range strings.Fields(personname.Key(title)) {
+		if numberingRegexp.MatchString(word) {
+			numberList = append(numberList, word)
+		}
+	}
+	return strings.Join(numberList, " ")
+}
+
+func bigrams(text string) map[string]int {
+	bigramMap := make(map[string]int)
+	for _, word := range strings.Fields(text) {
+		runeList := []rune(word)
+		if len(runeList) == 1 {
+			bigramMap[word]++
+		}
+		for i := 0; i+1 < len(runeList); i++ {
+			bigramMap[string(runeList[i:i+2])]++
+		}
+	}
+	return bigramMap
+}
+
+func countBigrams(bigramMap map[string]int) int {
+	total := 0
+	for _, count := range bigramMap {
+		total += count
+	}
+	return total
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
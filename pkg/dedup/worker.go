@@ -0,0 +1,122 @@
+package dedup
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/papertext"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	paperBatchSize     = 200
+	candidateLimit     = 50
+	reviewQueuePreview = 100
+)
+
+// DeduplicatePapers checks the papers not checked yet against the other papers: the confident duplicates are merged
+// into the oldest paper, the uncertain ones are queued for review
+// The papers saved without search title are normalised first (the candidates are selected on it)
+func DeduplicatePapers() error {
+	err := papertext.NormalisePapers()
+	if err != nil {
+		return err
+	}
+
+	mergedCount := 0
+	queuedCount := 0
+	var lastId database.ID
+	for {
+		paperList, err := database.GetPapersToDeduplicate(lastId, paperBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(paperList) == 0 {
+			break
+		}
+
+		for _, paper := range paperList {
+			lastId = paper.Id
+
+			// the paper may have been merged since the batch was fetched (i.e. as the candidate of a previous paper)
+			mergedIntoId, err := database.GetPaperMergedIntoId(paper.Id)
+			if err != nil {
+				return err
+			}
+			if mergedIntoId != nil {
+				log.Debugf("paper %d was merged into paper %d, skipping", paper.Id, *mergedIntoId)
+				continue
+			}
+
+			isMerged, isQueued, err := deduplicatePaper(paper)
+			if err != nil {
+				return err
+			}
+			if isMerged {
+				mergedCount++
+			}
+			if isQueued {
+				queuedCount++
+			}
+
+			err = database.MarkPaperDeduplicated(paper.Id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("deduplication done: %d papers merged, %d possible duplicates queued for review", mergedCount, queuedCount)
+	return nil
+}
+
+func deduplicatePaper(paper *database.DedupPaper) (bool, bool, error) {
+	candidateList, err := database.GetDuplicateCandidates(paper, candidateLimit)
+	if err != nil {
+		return false, false, err
+	}
+
+	var bestCandidate *database.DedupPaper
+	bestScore := 0.0
+	var bestReasonList []string
+	for _, candidate := range candidateList {
+		candidateScore, reasonList := score(paper, candidate)
+		if candidateScore > bestScore {
+			bestCandidate = candidate
+			bestScore = candidateScore
+			bestReasonList = reasonList
+		}
+	}
+
+	switch {
+	case bestCandidate == nil || bestScore < reviewThreshold:
+		return false, false, nil
+	case bestScore >= mergeThreshold:
+		// the oldest paper is the canonical one
+		canonicalId, duplicateId := bestCandidate.Id, paper.Id
+		if duplicateId < canonicalId {
+			canonicalId, duplicateId = duplicateId, canonicalId
+		}
+		return true, false, database.MergePapers(canonicalId, duplicateId)
+	default:
+		candidate := &database.PaperDuplicateCandidate{
+			Score:            bestScore,
+			Reasons:          bestReasonList,
+			PaperId:          paper.Id,
+			CandidatePaperId: bestCandidate.Id,
+		}
+		return false, true, candidate.Save()
+	}
+}
+
+// LogReviewQueue lists the possible duplicates waiting for review
+func LogReviewQueue() error {
+	candidateList, err := database.GetPendingDuplicateCandidates(reviewQueuePreview)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidateList {
+		log.Infof("candidate %d: paper %d / paper %d (score %.2f: %v)", candidate.Id, candidate.PaperId, candidate.CandidatePaperId, candidate.Score, candidate.Reasons)
+	}
+	log.Infof("%d possible duplicates listed (up to %d)", len(candidateList), reviewQueuePreview)
+	return nil
+}
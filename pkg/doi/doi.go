@@ -0,0 +1,96 @@
+package doi
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	doiStartPattern    = `(?i)10\.\d{4,9}/`
+	validDoiPattern    = `^10\.\d{4,9}/[^\s]+$`
+	trailingCharacters = ".,;:"
+)
+
+var doiStartRegexp = regexp.MustCompile(doiStartPattern)
+var validDoiRegexp = regexp.MustCompile(validDoiPattern)
+
+// prefixes stripped before the DOI itself (resolvers and labels)
+var prefixList = []string{
+	"https://doi.org/",
+	"http://doi.org/",
+	"https://dx.doi.org/",
+	"http://dx.doi.org/",
+	"doi.org/",
+	"dx.doi.org/",
+	"doi:",
+	"doi ",
+}
+
+// Parse returns the valid normalised DOIs of a text (e.g. `doi:10.1103/PhysRevD.98.030001, https://doi.org/10.1000/XYZ`)
+// A DOI extends up to the next whitespace or the start of the next DOI, without its trailing punctuation
+func Parse(text string) []string {
+	var doiList []string
+	doiSet := make(map[string]struct{})
+
+	// URL-encoded DOIs (e.g. `10.1016%2Fj.physletb`)
+	if decoded, err := url.PathUnescape(text); err == nil {
+		text = decoded
+	}
+
+	startIndexList := doiStartRegexp.FindAllStringIndex(text, -1)
+	for i, startIndex := range startIndexList {
+		end := len(text)
+		if i+1 < len(startIndexList) {
+			end = startIndexList[i+1][0]
+		}
+		candidate := text[startIndex[0]:end]
+		if whitespaceIndex := strings.IndexAny(candidate, " \t\r\n"); whitespaceIndex >= 0 {
+			candidate = candidate[:whitespaceIndex]
+		}
+		// the prefix of the next DOI (e.g. `10.1000/abc,doi:10.1000/def`)
+		for _, prefix := range prefixList {
+			if len(candidate) >= len(prefix) && strings.EqualFold(candidate[len(candidate)-len(prefix):], prefix) {
+				candidate = candidate[:len(candidate)-len(prefix)]
+			}
+		}
+
+		doi, isValid := Normalise(candidate)
+		if _, exists := doiSet[doi]; !isValid || exists {
+			continue
+		}
+		doiList = append(doiList, doi)
+		doiSet[doi] = struct{}{}
+	}
+
+	return doiList
+}
+
+// Normalise returns the canonical form of a DOI (no resolver prefix, URL-decoded, lower case) and whether it's valid
+// DOIs are case-insensitive, so the lower case form is used as comparison and dedup key
+func Normalise(rawDoi string) (string, bool) {
+	doi := strings.TrimSpace(rawDoi)
+	for _, prefix := range prefixList {
+		if len(doi) >= len(prefix) && strings.EqualFold(doi[:len(prefix)], prefix) {
+			doi = strings.TrimSpace(doi[len(prefix):])
+		}
+	}
+	if decoded, err := url.PathUnescape(doi); err == nil {
+		doi = decoded
+	}
+
+	doi = strings.TrimRight(doi, trailingCharacters)
+	doi = trimUnbalancedBracket(doi, '(', ')')
+	doi = trimUnbalancedBracket(doi, '[', ']')
+	doi = strings.ToLower(strings.TrimRight(doi, trailingCharacters))
+
+	return doi, validDoiRegexp.MatchString(doi)
+}
+
+// trimUnbalancedBracket removes a closing bracket ending the DOI without opening one (e.g. `(see 10.1000/xyz)`)
+func trimUnbalancedBracket(doi string, opening, closing byte) string {
+	if strings.HasSuffix(doi, string(closing)) && strings.Count(doi, string(opening)) < strings.Count(doi, string(closing)) {
+		return doi[:len(doi)-1]
+	}
+	return doi
+}
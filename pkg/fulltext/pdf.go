@@ -0,0 +1,84 @@
+package fulltext
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ledongthuc/pdf"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// a gap wider than this ratio of the font size is considered as a space between words
+	wordSpacingRatio = 0.15
+	// a vertical move larger than this ratio of the font size is considered as a new line
+	lineSpacingRatio = 0.5
+)
+
+// ExtractPdfPages returns the plain text of every page of a PDF
+// The glyphs are read in the content stream order (i.e. usually the reading order, even with several columns)
+func ExtractPdfPages(content []byte) (pageList []string, err error) {
+	// the PDF reader panics on malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			pageList = nil
+			err = fmt.Errorf("reading the PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("opening the PDF: %w", err)
+	}
+
+	pageCount := reader.NumPage()
+	for pageNumber := 1; pageNumber <= pageCount; pageNumber++ {
+		page := reader.Page(pageNumber)
+		if page.V.IsNull() {
+			pageList = append(pageList, "")
+			continue
+		}
+		pageList = append(pageList, layoutText(page.Content().Text))
+	}
+
+	return pageList, nil
+}
+
+// layoutText rebuilds the words and lines from the positioned glyphs
+func layoutText(textList []pdf.Text) string {
+	var builder strings.Builder
+	var previous *pdf.Text
+	for i := range textList {
+		text := &textList[i]
+		// skip the empty glyphs and the end of text block markers (the lines are rebuilt from the positions)
+		if text.S == "" || text.S == "\n" {
+			continue
+		}
+
+		if previous != nil {
+			fontSize := math.Max(math.Max(text.FontSize, previous.FontSize), 1)
+			isNewLine := math.Abs(text.Y-previous.Y) > lineSpacingRatio*fontSize || text.X < previous.X-fontSize
+			gap := text.X - (previous.X + previous.W)
+			switch {
+			case isNewLine:
+				builder.WriteString("\n")
+			case gap > wordSpacingRatio*fontSize && !strings.HasSuffix(previous.S, " ") && !strings.HasPrefix(text.S, " "):
+				builder.WriteString(" ")
+			}
+		}
+
+		builder.WriteString(text.S)
+		previous = text
+	}
+
+	return sanitize(builder.String())
+}
+
+// sanitize makes the text storable in PostgreSQL (valid UTF-8, no NUL character)
+func sanitize(text string) string {
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "�")
+	}
+	return strings.ReplaceAll(text, "\x00", "")
+}
@@ -0,0 +1,92 @@
+package fulltext
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/blob"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// ExtractDocuments extracts the text of the documents not processed yet, batch by batch until none is left
+// A failed extraction (i.e. PDF parsing error) is recorded (with its error) and isn't retried, while a document that
+// couldn't be read from the blob store is left pending for the next run
+func ExtractDocuments() error {
+	store, err := blob.NewStore()
+	if err != nil {
+		return fmt.Errorf("preparing the blob store: %w", err)
+	}
+
+	extractedCount := 0
+	failedCount := 0
+	skippedCount := 0
+	var lastId database.ID
+	for {
+		documentList, err := database.GetDocumentsWithoutExtraction(lastId, config.Extract.BatchSize)
+		if err != nil {
+			return fmt.Errorf("fetching the documents to extract: %w", err)
+		}
+		if len(documentList) == 0 {
+			break
+		}
+
+		for _, document := range documentList {
+			lastId = document.Id
+
+			extraction, err := extractDocument(store, document)
+			if err != nil {
+				log.Errorf("extracting the text of %s: %s", document.StorageKey, err)
+				skippedCount++
+				continue
+			}
+			if extraction.Error != nil {
+				log.Warnf("extracting the text of %s: %s", document.StorageKey, *extraction.Error)
+				failedCount++
+			} else {
+				extractedCount++
+			}
+
+			err = extraction.SaveWithPages()
+			if err != nil {
+				return fmt.Errorf("saving the extraction of %s: %w", document.StorageKey, err)
+			}
+		}
+	}
+
+	log.Infof("text extraction done: %d documents extracted, %d failures, %d skipped", extractedCount, failedCount, skippedCount)
+	return nil
+}
+
+// extractDocument returns the extraction of the document (with its error if the PDF couldn't be parsed), or an error if
+// the document couldn't be read
+func extractDocument(store blob.Store, document *database.Document) (*database.DocumentExtraction, error) {
+	extraction := &database.DocumentExtraction{
+		Version:     document.Version,
+		ExtractedAt: time.Now(),
+		DocumentId:  document.Id,
+		PaperId:     document.PaperId,
+	}
+
+	content, err := store.Get(document.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading the document: %w", err)
+	}
+
+	pageList, err := ExtractPdfPages(content)
+	if err != nil {
+		errorMessage := err.Error()
+		extraction.Error = &errorMessage
+		return extraction, nil
+	}
+
+	extraction.PageCount = len(pageList)
+	for i, pageText := range pageList {
+		extraction.Pages = append(extraction.Pages, &database.DocumentPage{
+			PageNumber: i + 1,
+			Text:       pageText,
+		})
+	}
+
+	return extraction, nil
+}
@@ -0,0 +1,148 @@
+package identifier
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/arxivid"
+	"github.com/papetier/scraper/pkg/doi"
+	"regexp"
+	"strings"
+)
+
+// the schemes of the papers' external identifiers
+const (
+	Arxiv           = "arxiv"
+	Doi             = "doi"
+	Pmid            = "pmid"
+	Pmcid           = "pmcid"
+	Dblp            = "dblp"
+	OpenAlex        = "openalex"
+	SemanticScholar = "s2"
+	Isbn            = "isbn"
+)
+
+const (
+	pmidPattern            = `^[1-9]\d{0,8}$`
+	pmcidPattern           = `^PMC\d+$`
+	dblpPattern            = `^[a-z]+(?:/[\w\-]+)+$`
+	openAlexPattern        = `^W\d+$`
+	semanticScholarPattern = `^(?:[0-9a-f]{40}|CorpusId:\d+)$`
+)
+
+var pmidRegexp = regexp.MustCompile(pmidPattern)
+var pmcidRegexp = regexp.MustCompile(pmcidPattern)
+var dblpRegexp = regexp.MustCompile(dblpPattern)
+var openAlexRegexp = regexp.MustCompile(openAlexPattern)
+var semanticScholarRegexp = regexp.MustCompile(semanticScholarPattern)
+
+// Normalise returns the canonical form of an identifier of the scheme (e.g. without resolver URL or label), or an error if invalid
+func Normalise(scheme string, value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	var normalised string
+	isValid := false
+	switch scheme {
+	case Arxiv:
+		if arxivId, err := arxivid.Parse(value); err == nil {
+			normalised, isValid = arxivId.Base, true
+		}
+	case Doi:
+		normalised, isValid = doi.Normalise(value)
+	case Pmid:
+		normalised = trimPrefixes(value, "https://pubmed.ncbi.nlm.nih.gov/", "pmid:", "pmid")
+		normalised = strings.TrimSpace(strings.TrimSuffix(normalised, "/"))
+		isValid = pmidRegexp.MatchString(normalised)
+	case Pmcid:
+		normalised = strings.ToUpper(trimPrefixes(value, "https://www.ncbi.nlm.nih.gov/pmc/articles/", "pmcid:"))
+		normalised = strings.TrimSuffix(normalised, "/")
+		if !strings.HasPrefix(normalised, "PMC") {
+			normalised = "PMC" + normalised
+		}
+		isValid = pmcidRegexp.MatchString(normalised)
+	case Dblp:
+		normalised = trimPrefixes(value, "https://dblp.org/rec/", "http://dblp.org/rec/", "dblp:")
+		normalised = strings.TrimSuffix(strings.TrimSuffix(normalised, ".html"), ".xml")
+		isValid = dblpRegexp.MatchString(normalised)
+	case OpenAlex:
+		normalised = strings.ToUpper(trimPrefixes(value, "https://openalex.org/", "openalex:"))
+		isValid = openAlexRegexp.MatchString(normalised)
+	case SemanticScholar:
+		normalised = trimPrefixes(value, "https://www.semanticscholar.org/paper/", "https://api.semanticscholar.org/")
+		if strings.HasPrefix(strings.ToLower(normalised), "corpusid:") {
+			normalised = "CorpusId:" + normalised[len("corpusid:"):]
+		} else {
+			// the paper URLs end with the id (e.g. `/paper/Attention-is-All-you-Need-Vaswani/204e3073...`)
+			normalised = strings.ToLower(normalised[strings.LastIndex(normalised, "/")+1:])
+		}
+		isValid = semanticScholarRegexp.MatchString(normalised)
+	case Isbn:
+		normalised, isValid = normaliseIsbn(value)
+	default:
+		return "", fmt.Errorf("unknown identifier scheme `%s`", scheme)
+	}
+
+	if !isValid {
+		return "", fmt.Errorf("invalid %s identifier `%s`", scheme, value)
+	}
+	return normalised, nil
+}
+
+// normaliseIsbn returns the ISBN-13 form of an ISBN-10 or ISBN-13 (without hyphens), checking its check digit
+func normaliseIsbn(value string) (string, bool) {
+	value = strings.ToUpper(trimPrefixes(value, "isbn:", "isbn-13:", "isbn-10:", "isbn"))
+	var digits []byte
+	for i := 0; i < len(value); i++ {
+		if (value[i] >= '0' && value[i] <= '9') || value[i] == 'X' {
+			digits = append(digits, value[i])
+		} else if value[i] != '-' && value[i] != ' ' {
+			return "", false
+		}
+	}
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, digit := range digits {
+			digitValue := int(digit - '0')
+			if digit == 'X' {
+				if i != 9 {
+					return "", false
+				}
+				digitValue = 10
+			}
+			sum += (10 - i) * digitValue
+		}
+		if sum%11 != 0 {
+			return "", false
+		}
+		isbn13 := append([]byte("978"), digits[:9]...)
+		return string(append(isbn13, isbn13CheckDigit(isbn13))), true
+	case 13:
+		if strings.IndexByte(string(digits), 'X') >= 0 || isbn13CheckDigit(digits[:12]) != digits[12] {
+			return "", false
+		}
+		return string(digits), true
+	default:
+		return "", false
+	}
+}
+
+func isbn13CheckDigit(digits []byte) byte {
+	sum := 0
+	for i, digit := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(digit-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func trimPrefixes(value string, prefixList ...string) string {
+	for _, prefix := range prefixList {
+		if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+			value = strings.TrimSpace(value[len(prefix):])
+		}
+	}
+	return value
+}
@@ -0,0 +1,118 @@
+package journalref
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	yearInBracketsPattern = `\(((?:19|20)\d{2})\)`
+	yearPattern           = `\b((?:19|20)\d{2})\b`
+	volumePattern         = `^(?:[Vv]ol(?:ume)?\.?\s*)?([A-Z]?\d+[A-Za-z]?)\b`
+	issuePattern          = `^\s*(?:\((\d+(?:[-–/]\d+)?)\)|,?\s*(?:[Nn]o\.?|[Ii]ssue|[Nn]umber)\s*(\d+(?:[-–/]\d+)?))`
+	pagesPattern          = `(?:^|[\s,:])(?:pp?\.\s*)?([A-Za-z]?\d+(?:\s*[-–]+\s*[A-Za-z]?\d+)?)\b`
+	minYear               = 1800
+	maxYear               = 2100
+)
+
+var yearInBracketsRegexp = regexp.MustCompile(yearInBracketsPattern)
+var yearRegexp = regexp.MustCompile(yearPattern)
+var volumeRegexp = regexp.MustCompile(volumePattern)
+var issueRegexp = regexp.MustCompile(issuePattern)
+var pagesRegexp = regexp.MustCompile(pagesPattern)
+
+// Reference is a parsed journal reference (e.g. `Phys. Rev. Lett. 120, 231101 (2018)`)
+type Reference struct {
+	Venue  *database.Venue
+	Volume *string
+	Issue  *string
+	Pages  *string
+	Year   *int
+}
+
+// Parse extracts the venue, volume, issue, pages and year of a journal reference (best effort)
+// The venue name is everything before the first number (i.e. the volume, or the year for proceedings)
+func Parse(journalRef string) *Reference {
+	reference := &Reference{}
+	text := strings.Join(strings.Fields(journalRef), " ")
+	if text == "" {
+		return reference
+	}
+
+	// year: in brackets or the last plausible one
+	yearText := ""
+	if result := yearInBracketsRegexp.FindStringSubmatch(text); len(result) > 1 {
+		yearText = result[1]
+		text = strings.Replace(text, result[0], " ", 1)
+	} else if resultList := yearRegexp.FindAllStringSubmatchIndex(text, -1); len(resultList) > 0 {
+		last := resultList[len(resultList)-1]
+		yearText = text[last[2]:last[3]]
+		text = text[:last[0]] + " " + text[last[1]:]
+	}
+	if year, err := strconv.Atoi(yearText); err == nil && year >= minYear && year <= maxYear {
+		reference.Year = &year
+	}
+
+	// venue: the words up to the first one with a digit
+	wordList := strings.Fields(text)
+	venueEnd := len(wordList)
+	for i, word := range wordList {
+		if strings.IndexAny(word, "0123456789") >= 0 || isVolumeWord(word) {
+			venueEnd = i
+			break
+		}
+	}
+	venueName := strings.Join(wordList[:venueEnd], " ")
+	rest := strings.TrimLeft(strings.Join(wordList[venueEnd:], " "), " ,")
+
+	// volume + issue
+	if result := volumeRegexp.FindStringSubmatchIndex(rest); result != nil {
+		volume := rest[result[2]:result[3]]
+		// the section letter may be glued to the volume (e.g. `Phys. Rev. D98`)
+		if section := volume[:1]; section >= "A" && section <= "Z" && isKnownVenue(venueName+" "+section) {
+			venueName += " " + section
+			volume = volume[1:]
+		}
+		reference.Volume = &volume
+		rest = rest[result[1]:]
+
+		if result = issueRegexp.FindStringSubmatchIndex(rest); result != nil {
+			var issue string
+			if result[2] >= 0 {
+				issue = rest[result[2]:result[3]]
+			} else {
+				issue = rest[result[4]:result[5]]
+			}
+			reference.Issue = &issue
+			rest = rest[result[1]:]
+		}
+	}
+
+	reference.Venue = NormaliseVenue(venueName)
+
+	// pages: page range, article number
+	if result := pagesRegexp.FindStringSubmatch(rest); len(result) > 1 {
+		pages := strings.Join(strings.Fields(strings.ReplaceAll(result[1], "–", "-")), "")
+		reference.Pages = &pages
+	}
+
+	return reference
+}
+
+// ApplyTo sets the venue, volume, issue, pages (and year, if missing) of the paper
+func (r *Reference) ApplyTo(paper *database.Paper) {
+	paper.Venue = r.Venue
+	paper.Volume = r.Volume
+	paper.Issue = r.Issue
+	paper.Pages = r.Pages
+	if paper.Year == nil {
+		paper.Year = r.Year
+	}
+}
+
+func isVolumeWord(word string) bool {
+	lowerWord := strings.ToLower(strings.TrimRight(word, "."))
+	return lowerWord == "vol" || lowerWord == "volume"
+}
@@ -0,0 +1,127 @@
+package journalref
+
+import (
+	_ "embed"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed venues.tsv
+var venuesFile string
+
+//go:embed publishers.tsv
+var publishersFile string
+
+// knownVenue is a venue of the abbreviation list, the first abbreviation of a name being its ISO4/NLM one
+type knownVenue struct {
+	name          string
+	abbreviation  string
+	publisherName string
+}
+
+var knownVenueMapByKey map[string]*knownVenue
+var publisherUrlMapByName map[string]string
+var loadVenuesOnce sync.Once
+
+// NormaliseVenue returns the venue (with its publisher, if known) of a venue name as written in a journal reference
+// Abbreviations (e.g. `Phys. Rev. Lett.`, `Phys.Rev.Lett.`, `PRL`) are mapped to the full name of the list
+func NormaliseVenue(rawName string) *database.Venue {
+	name := strings.TrimRight(strings.TrimSpace(rawName), ",;:")
+	if name == "" {
+		return nil
+	}
+
+	venue := FindKnownVenue(name)
+	if venue == nil {
+		return &database.Venue{
+			Name: name,
+		}
+	}
+	return venue
+}
+
+// FindKnownVenue returns the venue of the list (with its publisher, if known) matching a name or abbreviation, nil if none
+func FindKnownVenue(name string) *database.Venue {
+	loadVenuesOnce.Do(loadVenues)
+
+	known, exists := knownVenueMapByKey[venueKey(name)]
+	if !exists {
+		return nil
+	}
+
+	venue := &database.Venue{
+		Name:         known.name,
+		Abbreviation: &known.abbreviation,
+	}
+	if known.publisherName != "" {
+		venue.Publisher = &database.Publisher{
+			Name: known.publisherName,
+			Url:  publisherUrlMapByName[known.publisherName],
+		}
+	}
+	return venue
+}
+
+func isKnownVenue(name string) bool {
+	loadVenuesOnce.Do(loadVenues)
+	_, exists := knownVenueMapByKey[venueKey(name)]
+	return exists
+}
+
+// venueKey is the comparable form of a venue name: lower case letters and digits only (e.g. `physrevlett`)
+func venueKey(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.TrimPrefix(strings.ToLower(name), "the ") {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+func loadVenues() {
+	publisherUrlMapByName = make(map[string]string)
+	for _, fieldList := range readTsv(publishersFile) {
+		if len(fieldList) < 2 {
+			log.Warnf("skipping the malformed publisher line `%s`", strings.Join(fieldList, "\t"))
+			continue
+		}
+		publisherUrlMapByName[fieldList[0]] = fieldList[1]
+	}
+
+	knownVenueMapByKey = make(map[string]*knownVenue)
+	abbreviationMapByName := make(map[string]string)
+	for _, fieldList := range readTsv(venuesFile) {
+		if len(fieldList) < 2 {
+			log.Warnf("skipping the malformed venue line `%s`", strings.Join(fieldList, "\t"))
+			continue
+		}
+		name, abbreviation := fieldList[0], fieldList[1]
+		if _, exists := abbreviationMapByName[name]; !exists {
+			abbreviationMapByName[name] = abbreviation
+		}
+		known := &knownVenue{
+			name:         name,
+			abbreviation: abbreviationMapByName[name],
+		}
+		if len(fieldList) > 2 {
+			known.publisherName = fieldList[2]
+		}
+		knownVenueMapByKey[venueKey(name)] = known
+		knownVenueMapByKey[venueKey(abbreviation)] = known
+	}
+}
+
+func readTsv(content string) [][]string {
+	var lineList [][]string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineList = append(lineList, strings.Split(line, "\t"))
+	}
+	return lineList
+}
@@ -0,0 +1,40 @@
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// e.g. `http://creativecommons.org/licenses/by-nc-sa/4.0/`
+	creativeCommonsPattern = `(?i)creativecommons\.org/licenses/([a-z\-]+)/(\d\.\d)`
+	// e.g. `http://creativecommons.org/publicdomain/zero/1.0/`
+	creativeCommonsZeroPattern = `(?i)creativecommons\.org/publicdomain/zero/(\d\.\d)`
+	// the arXiv's own licenses, e.g. `http://arxiv.org/licenses/nonexclusive-distrib/1.0/`
+	arxivLicensePattern = `(?i)arxiv\.org/licenses/([a-z\-]+)/(\d\.\d)`
+
+	// the identifiers prefix of the licenses not in the SPDX list
+	licenseRefPrefix = "LicenseRef-"
+)
+
+var creativeCommonsRegexp = regexp.MustCompile(creativeCommonsPattern)
+var creativeCommonsZeroRegexp = regexp.MustCompile(creativeCommonsZeroPattern)
+var arxivLicenseRegexp = regexp.MustCompile(arxivLicensePattern)
+
+// Normalise returns the SPDX-like identifier of a license URL (e.g. `CC-BY-4.0`, `CC0-1.0`,
+// `LicenseRef-arXiv-nonexclusive-distrib-1.0`), and whether the license is known
+func Normalise(licenseUrl string) (string, bool) {
+	if result := creativeCommonsZeroRegexp.FindStringSubmatch(licenseUrl); result != nil {
+		return "CC0-" + result[1], true
+	}
+	if result := creativeCommonsRegexp.FindStringSubmatch(licenseUrl); result != nil {
+		return "CC-" + strings.ToUpper(result[1]) + "-" + result[2], true
+	}
+	if result := arxivLicenseRegexp.FindStringSubmatch(licenseUrl); result != nil {
+		return licenseRefPrefix + "arXiv-" + strings.ToLower(result[1]) + "-" + result[2], true
+	}
+	if strings.Contains(strings.ToLower(licenseUrl), "creativecommons.org/licenses/publicdomain") {
+		return licenseRefPrefix + "public-domain", true
+	}
+	return "", false
+}
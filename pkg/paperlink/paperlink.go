@@ -0,0 +1,165 @@
+package paperlink
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// the code and data hosts, with or without scheme (e.g. `github.com/owner/repo`, `https://owner.github.io/project`),
+	// and the Zenodo DOIs (e.g. `https://doi.org/10.5281/zenodo.1234567`), up to a LaTeX group (e.g. `\href{url}{text}`)
+	linkPattern = `(?i)\b(?:https?://)?(?:www\.)?(?:[a-z0-9\-]+\.github\.io|github\.com|gitlab\.com|bitbucket\.org|huggingface\.co|zenodo\.org|(?:dx\.)?doi\.org/10\.5281)(?:/[^\s,;<>"'{}]*)?`
+	// e.g. `10.5281/zenodo.1234567`, `zenodo.org/record/1234567`
+	zenodoRecordPattern = `(?i)(?:zenodo\.|records?/)(\d+)`
+	trailingCharacters  = ".:)]}"
+	// the text before a link looked up for its kind (e.g. `the dataset is available at`)
+	contextLength = 60
+)
+
+var linkRegexp = regexp.MustCompile(linkPattern)
+var zenodoRecordRegexp = regexp.MustCompile(zenodoRecordPattern)
+
+// the LaTeX escaped characters of the URLs (e.g. `github.com/owner/my\_repo`)
+var latexEscapeReplacer = strings.NewReplacer(`\_`, "_", `\%`, "%", `\&`, "&", `\#`, "#", `\~`, "~")
+
+// the hosts whose owners and repositories are case-insensitive (lower case in the canonical URL)
+var caseInsensitiveHostSet = map[string]struct{}{
+	"github.com": {}, "gitlab.com": {}, "bitbucket.org": {}, "huggingface.co": {},
+}
+
+// the words of the context stating the kind of a link, overriding the host's default kind
+var kindKeywordListByKind = map[string][]string{
+	database.DatasetLinkKind:     {"dataset", "data set", "data is", "data are", "benchmark", "corpus"},
+	database.ModelLinkKind:       {"model", "weights", "checkpoint"},
+	database.ProjectPageLinkKind: {"project page", "website", "homepage", "demo"},
+	database.CodeLinkKind:        {"code", "implementation", "software", "library", "package", "source"},
+}
+
+// the kinds are looked up in this order (after the default one), e.g. `dataset and model at` is a dataset
+var kindList = []string{database.DatasetLinkKind, database.ModelLinkKind, database.ProjectPageLinkKind, database.CodeLinkKind}
+
+// the first path segments of the hosts which aren't repositories, e.g. `github.com/topics/...`
+var reservedSegmentSet = map[string]struct{}{
+	"about": {}, "explore": {}, "features": {}, "marketplace": {}, "orgs": {}, "settings": {}, "sponsors": {}, "topics": {},
+	"docs": {}, "papers": {}, "blog": {}, "search": {}, "login": {}, "signup": {},
+}
+
+// Parse returns the normalised code, dataset, project page and model links of a text (e.g. an abstract)
+// The repositories are reduced to their root (e.g. `https://github.com/owner/repo`)
+func Parse(text string, source string) []*database.PaperLink {
+	var linkList []*database.PaperLink
+	urlSet := make(map[string]struct{})
+
+	previousEnd := 0
+	for _, index := range linkRegexp.FindAllStringIndex(text, -1) {
+		contextStart := index[0] - contextLength
+		if contextStart < previousEnd {
+			contextStart = previousEnd
+		}
+		context := strings.ToLower(text[contextStart:index[0]])
+		previousEnd = index[1]
+
+		paperLink := normalise(strings.TrimRight(text[index[0]:index[1]], trailingCharacters), context)
+		if paperLink == nil {
+			continue
+		}
+		if _, exists := urlSet[paperLink.Url]; exists {
+			continue
+		}
+		paperLink.Source = source
+		paperLink.FirstSeenAt = time.Now()
+		linkList = append(linkList, paperLink)
+		urlSet[paperLink.Url] = struct{}{}
+	}
+
+	return linkList
+}
+
+// normalise returns the link with its canonical URL and kind, nil if it isn't a code, dataset, project page or model one
+func normalise(rawUrl string, context string) *database.PaperLink {
+	rawUrl = latexEscapeReplacer.Replace(rawUrl)
+	rawUrl = strings.TrimPrefix(strings.TrimPrefix(rawUrl, "https://"), "http://")
+	rawUrl = strings.TrimPrefix(rawUrl, "www.")
+	if index := strings.IndexAny(rawUrl, "?#"); index >= 0 {
+		rawUrl = rawUrl[:index]
+	}
+	segmentList := strings.Split(strings.Trim(rawUrl, "/"), "/")
+	host := strings.ToLower(segmentList[0])
+	pathList := segmentList[1:]
+	if _, isCaseInsensitive := caseInsensitiveHostSet[host]; isCaseInsensitive {
+		for i, segment := range pathList {
+			pathList[i] = strings.ToLower(segment)
+		}
+	}
+
+	switch {
+	case host == "github.com" || host == "bitbucket.org":
+		if len(pathList) < 2 || isReserved(pathList[0]) {
+			return nil
+		}
+		return newLink(host, pathList[:2], getContextKind(context, database.CodeLinkKind))
+	case host == "gitlab.com":
+		// the project may be in subgroups, its pages start with `-` (e.g. `gitlab.com/group/subgroup/repo/-/tree/main`)
+		for i, segment := range pathList {
+			if segment == "-" {
+				pathList = pathList[:i]
+				break
+			}
+		}
+		if len(pathList) < 2 || isReserved(pathList[0]) {
+			return nil
+		}
+		return newLink(host, pathList, getContextKind(context, database.CodeLinkKind))
+	case host == "huggingface.co":
+		if len(pathList) >= 3 && pathList[0] == "datasets" {
+			return newLink(host, pathList[:3], database.DatasetLinkKind)
+		}
+		if len(pathList) >= 3 && pathList[0] == "spaces" {
+			return newLink(host, pathList[:3], database.ProjectPageLinkKind)
+		}
+		if len(pathList) < 2 || isReserved(pathList[0]) {
+			return nil
+		}
+		return newLink(host, pathList[:2], database.ModelLinkKind)
+	case host == "zenodo.org" || strings.HasSuffix(host, "doi.org"):
+		result := zenodoRecordRegexp.FindStringSubmatch(strings.Join(pathList, "/"))
+		if result == nil {
+			return nil
+		}
+		return newLink("zenodo.org", []string{"records", result[1]}, getContextKind(context, database.DatasetLinkKind))
+	case strings.HasSuffix(host, ".github.io"):
+		return newLink(host, pathList, database.ProjectPageLinkKind)
+	}
+	return nil
+}
+
+func newLink(host string, pathList []string, kind string) *database.PaperLink {
+	url := "https://" + host
+	if len(pathList) > 0 && pathList[0] != "" {
+		url += "/" + strings.Join(pathList, "/")
+	}
+	return &database.PaperLink{
+		Url:  strings.TrimSuffix(url, ".git"),
+		Host: host,
+		Kind: kind,
+	}
+}
+
+// getContextKind returns the kind stated by the context of the link, the default one if none (or also stated)
+func getContextKind(context string, defaultKind string) string {
+	for _, kind := range append([]string{defaultKind}, kindList...) {
+		for _, keyword := range kindKeywordListByKind[kind] {
+			if strings.Contains(context, keyword) {
+				return kind
+			}
+		}
+	}
+	return defaultKind
+}
+
+func isReserved(segment string) bool {
+	_, exists := reservedSegmentSet[strings.ToLower(segment)]
+	return exists
+}
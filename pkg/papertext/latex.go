@@ -0,0 +1,292 @@
+package papertext
+
+import (
+	"github.com/papetier/scraper/pkg/personname"
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// the math segments: `$$...$$`, `$...$`, `\(...\)`, `\[...\]`
+	mathPattern = `\$\$(.+?)\$\$|\$(.+?)\$|\\\((.+?)\\\)|\\\[(.+?)\\\]`
+	// the accented letters with balanced braces, e.g. `\'e`, `{\'e}`, `\'{e}`, `\c{c}`, `{\v s}`
+	accentPattern = `\{\\(?:["'` + "`" + `^~=.]|[uvHcdbkr]\s)\s*(?:\\[ij]|[A-Za-z])\}|` +
+		`\\(?:["'` + "`" + `^~=.]|[uvHcdbkr])\s*\{(?:\\[ij]|[A-Za-z])\}|` +
+		`\\(?:["'` + "`" + `^~=.]\s*|[uvHcdbkr]\s+)(?:\\[ij]|[A-Za-z])`
+	// the commands with two arguments, e.g. `\frac{a}{b}`
+	twoArgumentPattern = `\\([dt]?frac|href)\s*\{([^{}]*)\}\s*\{([^{}]*)\}`
+	// the commands, subscripts and superscripts with an argument without group (i.e. the innermost ones first)
+	argumentPattern = `(\\[A-Za-z]+\*?|[\^_])\s*\{([^{}]*)\}`
+	commandPattern  = `\\([A-Za-z]+)\*?`
+	// the single letter subscripts and superscripts, e.g. `x^2`, `x_i` (not the bracketed ones left, e.g. `x^(n/2)`)
+	scriptPattern = `([\^_])\s*([^\s{}(])`
+	// the spaces left before a punctuation mark (e.g. by a dropped citation)
+	spaceBeforePunctuationPattern = `\s+([.,;:!?])`
+	// the nesting depth of the groups converted
+	maxGroupDepth = 10
+	// the operators making a fraction's numerator or denominator bracketed, e.g. `(a+b)/2`
+	fractionOperators = "+-−·×/ "
+)
+
+// the escaped characters are kept as (private use) placeholders until the braces and math delimiters are removed
+const (
+	dollarPlaceholder     = "\uE000"
+	openBracePlaceholder  = "\uE001"
+	closeBracePlaceholder = "\uE002"
+	underscorePlaceholder = "\uE003"
+)
+
+// the commands of single letter accents, e.g. `\c{c}`
+const accentCommandLetters = "uvHcdbkr"
+
+var mathRegexp = regexp.MustCompile(mathPattern)
+var accentRegexp = regexp.MustCompile(accentPattern)
+var twoArgumentRegexp = regexp.MustCompile(twoArgumentPattern)
+var argumentRegexp = regexp.MustCompile(argumentPattern)
+var commandRegexp = regexp.MustCompile(commandPattern)
+var scriptRegexp = regexp.MustCompile(scriptPattern)
+var spaceBeforePunctuationRegexp = regexp.MustCompile(spaceBeforePunctuationPattern)
+
+var escapeReplacer = strings.NewReplacer(
+	`\\`, " ",
+	`\$`, dollarPlaceholder,
+	`\{`, openBracePlaceholder,
+	`\}`, closeBracePlaceholder,
+	`\_`, underscorePlaceholder,
+	`\|`, "‖",
+	`\%`, "%",
+	`\&`, "&",
+	`\#`, "#",
+	`\,`, " ",
+	`\;`, " ",
+	`\:`, " ",
+	`\ `, " ",
+	`\!`, "",
+)
+var textReplacer = strings.NewReplacer("---", "—", "--", "–", "``", "“", "''", "”")
+var braceReplacer = strings.NewReplacer("{", "", "}", "", "~", " ")
+var placeholderReplacer = strings.NewReplacer(
+	dollarPlaceholder, "$",
+	openBracePlaceholder, "{",
+	closeBracePlaceholder, "}",
+	underscorePlaceholder, "_",
+)
+
+// render converts the LaTeX of a text into Unicode (e.g. `$\alpha$-stable` → `α-stable`), or spelled out for the search
+// version (e.g. `alpha -stable`)
+func render(text string, forSearch bool) string {
+	text = escapeReplacer.Replace(strings.Join(strings.Fields(text), " "))
+	text = accentRegexp.ReplaceAllStringFunc(text, personname.DecodeLatex)
+
+	text = mathRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		for _, content := range mathRegexp.FindStringSubmatch(match)[1:] {
+			if content != "" {
+				return convertMath(content, forSearch)
+			}
+		}
+		return ""
+	})
+	text = convertCommands(text, false, forSearch)
+
+	if forSearch {
+		text = braceReplacer.Replace(text)
+	} else {
+		text = braceReplacer.Replace(textReplacer.Replace(text))
+		text = spaceBeforePunctuationRegexp.ReplaceAllString(text, "$1")
+	}
+	text = placeholderReplacer.Replace(text)
+
+	return strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+}
+
+func convertMath(content string, forSearch bool) string {
+	content = convertCommands(content, true, forSearch)
+	if forSearch {
+		return " " + strings.NewReplacer("^", " ", "_", " ").Replace(content) + " "
+	}
+	content = scriptRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		result := scriptRegexp.FindStringSubmatch(match)
+		return convertScript(result[1], result[2], false)
+	})
+	return strings.NewReplacer("'", "′", "-", "−").Replace(content)
+}
+
+// convertCommands converts the commands (innermost groups first), then the symbols
+func convertCommands(text string, isMath bool, forSearch bool) string {
+	for i := 0; i < maxGroupDepth; i++ {
+		converted := twoArgumentRegexp.ReplaceAllStringFunc(text, func(match string) string {
+			result := twoArgumentRegexp.FindStringSubmatch(match)
+			return convertTwoArguments(result[1], result[2], result[3], forSearch)
+		})
+		converted = argumentRegexp.ReplaceAllStringFunc(converted, func(match string) string {
+			result := argumentRegexp.FindStringSubmatch(match)
+			return convertArgument(match, result[1], result[2], isMath, forSearch)
+		})
+		if converted == text {
+			break
+		}
+		text = converted
+	}
+
+	return convertSymbols(text, forSearch)
+}
+
+func convertTwoArguments(command string, first string, second string, forSearch bool) string {
+	if command == "href" {
+		return second
+	}
+	if forSearch {
+		return " " + first + " " + second + " "
+	}
+	return bracketFractionPart(convertSymbols(first, false)) + "/" + bracketFractionPart(convertSymbols(second, false))
+}
+
+func convertArgument(match string, prefix string, content string, isMath bool, forSearch bool) string {
+	content = convertSymbols(content, forSearch)
+	if prefix == "^" || prefix == "_" {
+		if !isMath {
+			return content
+		}
+		return convertScript(prefix, content, forSearch)
+	}
+
+	command := strings.TrimSuffix(strings.TrimPrefix(prefix, `\`), "*")
+	if strings.HasSuffix(command, "frac") || command == "href" {
+		// the first argument of a command with two, left for the next pass
+		return match
+	}
+	if _, exists := droppedArgumentCommandSet[command]; exists {
+		return ""
+	}
+	if forSearch {
+		return " " + content + " "
+	}
+
+	switch {
+	case command == "mathbb":
+		return mapLetters(content, doubleStruckMap, doubleStruckCapitalStart, doubleStruckSmallStart, doubleStruckDigitStart)
+	case command == "mathcal" || command == "mathscr":
+		return mapLetters(content, calligraphicMap, calligraphicCapitalStart, 0, 0)
+	case command == "sqrt":
+		return symbolMap["sqrt"] + bracketFractionPart(content)
+	case len(command) == 1 && strings.Contains(accentCommandLetters, command):
+		// an accent not on a single letter (the others were decoded first)
+		return content
+	}
+	if accent, exists := mathAccentMap[command]; exists {
+		if utf8.RuneCountInString(content) == 1 {
+			return content + accent
+		}
+		return content
+	}
+	// the symbols followed by a group (e.g. `\in{A}`), the fonts and text commands (e.g. `\mathrm{d}`, `\emph{word}`)
+	if symbol, exists := symbolMap[command]; exists {
+		return symbol + content
+	}
+	return content
+}
+
+// convertScript returns the Unicode subscript or superscript of the content, if they all exist (e.g. `x^{-1}` → `x⁻¹`)
+func convertScript(marker string, content string, forSearch bool) string {
+	content = strings.TrimSpace(content)
+	if forSearch {
+		return " " + content + " "
+	}
+
+	runeMap := superscriptMap
+	if marker == "_" {
+		runeMap = subscriptMap
+	}
+	var builder strings.Builder
+	for _, r := range content {
+		scriptRune, exists := runeMap[r]
+		if !exists {
+			if utf8.RuneCountInString(content) == 1 {
+				return marker + content
+			}
+			return marker + "(" + content + ")"
+		}
+		builder.WriteRune(scriptRune)
+	}
+	return builder.String()
+}
+
+// convertSymbols replaces the symbol commands by their Unicode symbol, the other commands by their name (e.g. `\log`),
+// the operator names being separated from the word or number before and the letter after (e.g. `n\log n` → `n log n`,
+// `\sin\theta` → `sin θ`)
+func convertSymbols(text string, forSearch bool) string {
+	var builder strings.Builder
+	previousEnd := 0
+	for _, index := range commandRegexp.FindAllStringSubmatchIndex(text, -1) {
+		builder.WriteString(text[previousEnd:index[0]])
+		converted := convertSymbol(text[index[2]:index[3]], forSearch)
+		if _, isOperator := operatorNameSet[text[index[2]:index[3]]]; isOperator && !forSearch {
+			if previousRune, _ := utf8.DecodeLastRuneInString(text[:index[0]]); unicode.IsLetter(previousRune) || unicode.IsDigit(previousRune) {
+				converted = " " + converted
+			}
+			if nextRune, _ := utf8.DecodeRuneInString(text[index[1]:]); nextRune == '\\' || unicode.IsLetter(nextRune) {
+				converted += " "
+			}
+		}
+		builder.WriteString(converted)
+		previousEnd = index[1]
+	}
+	builder.WriteString(text[previousEnd:])
+	return builder.String()
+}
+
+// convertSymbol returns the Unicode symbol of a command, its name if none (spelled out in the search version)
+func convertSymbol(command string, forSearch bool) string {
+	if letter, exists := greekLetterMap[command]; exists {
+		if forSearch {
+			return " " + command + " "
+		}
+		return letter
+	}
+	if letter, exists := letterMap[command]; exists {
+		return letter
+	}
+	if symbol, exists := symbolMap[command]; exists {
+		if forSearch {
+			return " "
+		}
+		return symbol
+	}
+	if forSearch {
+		return " " + command + " "
+	}
+	return command
+}
+
+// mapLetters returns the letters of a Mathematical Alphanumeric Symbols alphabet (e.g. `R` → `ℝ`), the start of the
+// small letters and digits being 0 if they don't exist
+func mapLetters(text string, exceptionMap map[rune]rune, capitalStart rune, smallStart rune, digitStart rune) string {
+	var builder strings.Builder
+	for _, r := range text {
+		mappedRune, exists := exceptionMap[r]
+		switch {
+		case exists:
+		case r >= 'A' && r <= 'Z':
+			mappedRune = capitalStart + r - 'A'
+		case r >= 'a' && r <= 'z' && smallStart != 0:
+			mappedRune = smallStart + r - 'a'
+		case r >= '0' && r <= '9' && digitStart != 0:
+			mappedRune = digitStart + r - '0'
+		default:
+			mappedRune = r
+		}
+		builder.WriteRune(mappedRune)
+	}
+	return builder.String()
+}
+
+func bracketFractionPart(part string) string {
+	part = strings.TrimSpace(part)
+	if utf8.RuneCountInString(part) > 1 && strings.ContainsAny(part, fractionOperators) {
+		return "(" + part + ")"
+	}
+	return part
+}
@@ -0,0 +1,59 @@
+package papertext
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/personname"
+	log "github.com/sirupsen/logrus"
+)
+
+// the papers normalised per batch by NormalisePapers
+const normaliseBatchSize = 1000
+
+// Display returns the clean display version of a title or abstract with raw LaTeX and hard line wraps: Unicode math
+// where possible (e.g. `$\alpha \leq 2^{n}$` → `α ≤ 2ⁿ`), accents decoded, text commands and braces removed,
+// whitespace collapsed
+func Display(text string) string {
+	return render(text, false)
+}
+
+// Search returns the plain search version of a title or abstract: ASCII-folded lower case words, the Greek letters
+// and commands spelled out (e.g. `$\alpha$-stable Lévy` → `alpha stable levy`), without symbols nor punctuation
+func Search(text string) string {
+	return personname.Key(render(text, true))
+}
+
+// Normalise sets the display and search versions of the paper's title and abstract
+func Normalise(paper *database.Paper) {
+	paper.DisplayTitle = Display(paper.Title)
+	paper.DisplayAbstract = Display(paper.Abstract)
+	paper.SearchTitle = Search(paper.Title)
+	paper.SearchAbstract = Search(paper.Abstract)
+}
+
+// NormalisePapers sets the display and search versions of the papers saved without them
+func NormalisePapers() error {
+	normalisedCount := 0
+	for {
+		paperList, err := database.GetPapersWithoutDisplayText(normaliseBatchSize)
+		if err != nil {
+			return fmt.Errorf("fetching the papers to normalise: %w", err)
+		}
+		if len(paperList) == 0 {
+			break
+		}
+
+		for _, paper := range paperList {
+			Normalise(paper)
+		}
+		err = database.SavePapersDisplayText(paperList)
+		if err != nil {
+			return fmt.Errorf("saving the normalised papers: %w", err)
+		}
+		normalisedCount += len(paperList)
+		log.Infof("%d papers normalised", normalisedCount)
+	}
+
+	log.Infof("text normalisation done: %d papers normalised", normalisedCount)
+	return nil
+}
@@ -0,0 +1,85 @@
+package papertext
+
+// greekLetterMap maps the Greek letter commands to their Unicode letter (spelled out in the search version)
+var greekLetterMap = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε", "zeta": "ζ", "eta": "η",
+	"theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π",
+	"varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ",
+	"Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// letterMap maps the special letter commands to their Unicode letter (kept in the search version)
+var letterMap = map[string]string{
+	"ss": "ß", "aa": "å", "AA": "Å", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ", "o": "ø", "O": "Ø", "l": "ł", "L": "Ł",
+	"i": "ı", "j": "ȷ", "ell": "ℓ", "hbar": "ℏ", "aleph": "ℵ", "LaTeX": "LaTeX", "TeX": "TeX",
+}
+
+// symbolMap maps the symbol commands to their Unicode symbol (dropped in the search version)
+var symbolMap = map[string]string{
+	"infty": "∞", "leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈", "sim": "∼",
+	"simeq": "≃", "equiv": "≡", "propto": "∝", "ll": "≪", "gg": "≫", "lesssim": "≲", "gtrsim": "≳",
+	"times": "×", "cdot": "·", "pm": "±", "mp": "∓", "div": "÷", "ast": "∗", "star": "⋆", "circ": "∘", "bullet": "•",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"leftrightarrow": "↔", "Leftrightarrow": "⇔", "iff": "⇔", "mapsto": "↦", "longrightarrow": "⟶",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇", "cup": "∪",
+	"cap": "∩", "setminus": "∖", "emptyset": "∅", "varnothing": "∅", "forall": "∀", "exists": "∃", "neg": "¬",
+	"lnot": "¬", "wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨", "otimes": "⊗", "oplus": "⊕", "perp": "⊥",
+	"parallel": "∥", "angle": "∠", "partial": "∂", "nabla": "∇", "sum": "∑", "prod": "∏", "int": "∫", "oint": "∮",
+	"sqrt": "√", "Re": "ℜ", "Im": "ℑ", "prime": "′", "dagger": "†", "ldots": "…", "dots": "…", "cdots": "⋯",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "vert": "|", "mid": "|",
+	"Vert": "‖", "degree": "°", "textendash": "–", "textemdash": "—", "S": "§", "copyright": "©",
+	// spacing and sizing
+	"quad": " ", "qquad": " ", "left": "", "right": "", "big": "", "Big": "", "bigg": "", "Bigg": "",
+	"displaystyle": "", "textstyle": "", "limits": "", "nolimits": "", "newline": " ", "noindent": "",
+	// font switches, e.g. `{\em word}`
+	"em": "", "it": "", "bf": "", "rm": "", "sc": "", "tt": "", "sf": "",
+}
+
+// the operator names (e.g. `\log`), kept as words
+var operatorNameSet = map[string]struct{}{
+	"log": {}, "ln": {}, "lg": {}, "exp": {}, "sin": {}, "cos": {}, "tan": {}, "cot": {}, "sec": {}, "csc": {},
+	"arcsin": {}, "arccos": {}, "arctan": {}, "sinh": {}, "cosh": {}, "tanh": {}, "coth": {}, "max": {}, "min": {},
+	"sup": {}, "inf": {}, "lim": {}, "liminf": {}, "limsup": {}, "argmax": {}, "argmin": {}, "det": {}, "dim": {},
+	"ker": {}, "deg": {}, "arg": {}, "gcd": {}, "hom": {}, "Pr": {}, "poly": {}, "polylog": {},
+}
+
+// the commands whose argument is dropped (e.g. `\cite{key}`)
+var droppedArgumentCommandSet = map[string]struct{}{
+	"cite": {}, "citep": {}, "citet": {}, "ref": {}, "eqref": {}, "label": {},
+}
+
+// the accents of the commands whose argument is a single letter (e.g. `\hat{x}` → `x̂`)
+var mathAccentMap = map[string]string{
+	"hat": "̂", "widehat": "̂", "tilde": "̃", "widetilde": "̃", "bar": "̄",
+	"overline": "̅", "vec": "⃗", "dot": "̇", "ddot": "̈",
+}
+
+var superscriptMap = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ', 'k': 'ᵏ',
+	'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ', 'w': 'ʷ',
+	'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ', 'T': 'ᵀ',
+	// already raised
+	'′': '′', '*': '*', '∗': '*', '†': '†',
+}
+
+var subscriptMap = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '−': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'p': 'ₚ',
+	'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
+
+// the double-struck and calligraphic letters outside of the Mathematical Alphanumeric Symbols block
+var doubleStruckMap = map[rune]rune{'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ'}
+var calligraphicMap = map[rune]rune{'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ'}
+
+const (
+	doubleStruckCapitalStart = 0x1d538
+	doubleStruckSmallStart   = 0x1d552
+	doubleStruckDigitStart   = 0x1d7d8
+	calligraphicCapitalStart = 0x1d49c
+)
@@ -0,0 +1,59 @@
+package personname
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// asciiLetters are the letters not decomposed into an ASCII letter + combining marks
+var asciiLetters = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'Æ': "AE",
+	'œ': "oe",
+	'Œ': "OE",
+	'ø': "o",
+	'Ø': "O",
+	'ł': "l",
+	'Ł': "L",
+	'đ': "d",
+	'Đ': "D",
+	'ð': "d",
+	'Ð': "D",
+	'þ': "th",
+	'Þ': "TH",
+	'ı': "i",
+	'ȷ': "j",
+}
+
+var apostropheReplacer = strings.NewReplacer("'", "", "’", "")
+
+// FoldToAscii removes the diacritics and transliterates the special Latin letters (e.g. `Łukasz Müller` → `Lukasz Muller`)
+func FoldToAscii(text string) string {
+	var builder strings.Builder
+	for _, r := range norm.NFD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining mark
+		case r < unicode.MaxASCII:
+			builder.WriteRune(r)
+		default:
+			if letters, exists := asciiLetters[r]; exists {
+				builder.WriteString(letters)
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// Key is the comparable form of a text: ASCII-folded, lower case, without punctuation, single spaces
+func Key(text string) string {
+	folded := strings.ToLower(FoldToAscii(apostropheReplacer.Replace(text)))
+	tokenList := strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(tokenList, " ")
+}
@@ -0,0 +1,75 @@
+package personname
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+)
+
+// latexAccentPattern matches the accent commands with their letter, e.g. `\"o`, `\"{o}`, `{\"o}`, `\c{c}`, `\v s`
+const latexAccentPattern = `\{?\\(["'` + "`" + `^~=.]|[uvHcdbkr](?:\s+|(?:\{)))\s*\{?(\\[ij]|[A-Za-z])\}?\}?`
+
+var latexAccentRegexp = regexp.MustCompile(latexAccentPattern)
+
+// latexAccents maps the accent commands to their Unicode combining character
+var latexAccents = map[string]string{
+	`"`: "̈",
+	`'`: "́",
+	"`": "̀",
+	`^`: "̂",
+	`~`: "̃",
+	`=`: "̄",
+	`.`: "̇",
+	`u`: "̆",
+	`v`: "̌",
+	`H`: "̋",
+	`c`: "̧",
+	`d`: "̣",
+	`b`: "̱",
+	`k`: "̨",
+	`r`: "̊",
+}
+
+// latexLetters maps the letter commands to their Unicode letter (longest first for the replacer)
+var latexLetters = []string{
+	`{\ss}`, "ß", `\ss`, "ß",
+	`{\aa}`, "å", `\aa`, "å",
+	`{\AA}`, "Å", `\AA`, "Å",
+	`{\ae}`, "æ", `\ae`, "æ",
+	`{\AE}`, "Æ", `\AE`, "Æ",
+	`{\oe}`, "œ", `\oe`, "œ",
+	`{\OE}`, "Œ", `\OE`, "Œ",
+	`{\o}`, "ø", `\o`, "ø",
+	`{\O}`, "Ø", `\O`, "Ø",
+	`{\l}`, "ł", `\l`, "ł",
+	`{\L}`, "Ł", `\L`, "Ł",
+	`{\i}`, "ı", `{\j}`, "ȷ",
+	`\&`, "&",
+	`~`, " ",
+}
+
+var latexLettersReplacer = strings.NewReplacer(latexLetters...)
+
+// DecodeLatex replaces the LaTeX accents and special letters by their Unicode form (NFC) and drops the remaining braces
+func DecodeLatex(text string) string {
+	if !strings.ContainsAny(text, `\{}~`) {
+		return norm.NFC.String(text)
+	}
+
+	decoded := latexAccentRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		result := latexAccentRegexp.FindStringSubmatch(match)
+		command := strings.TrimRight(strings.TrimSpace(result[1]), "{")
+		letter := result[2]
+		switch letter {
+		case `\i`:
+			letter = "i"
+		case `\j`:
+			letter = "j"
+		}
+		return letter + latexAccents[command]
+	})
+	decoded = latexLettersReplacer.Replace(decoded)
+	decoded = strings.NewReplacer("{", "", "}", "").Replace(decoded)
+
+	return norm.NFC.String(decoded)
+}
@@ -0,0 +1,140 @@
+package personname
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Name is a person name split into its parts (e.g. `Johannes Diderik van der Waals Jr.`)
+type Name struct {
+	Given    string // Johannes Diderik
+	Particle string // van der
+	Family   string // Waals
+	Suffix   string // Jr.
+}
+
+var particles = map[string]struct{}{
+	"af": {}, "al": {}, "bin": {}, "da": {}, "das": {}, "de": {}, "degli": {}, "dei": {}, "del": {}, "della": {},
+	"delle": {}, "den": {}, "der": {}, "des": {}, "di": {}, "do": {}, "dos": {}, "du": {}, "el": {}, "ibn": {},
+	"la": {}, "le": {}, "lo": {}, "op": {}, "te": {}, "ten": {}, "ter": {}, "van": {}, "von": {}, "y": {}, "zu": {},
+}
+
+var suffixes = map[string]struct{}{
+	"jr": {}, "sr": {}, "ii": {}, "iii": {}, "iv": {}, "v": {}, "phd": {}, "md": {},
+}
+
+// Parse splits a raw name (`Given Family`, `Family, Given` or `Family, Suffix, Given`, possibly with LaTeX accents)
+func Parse(rawName string) *Name {
+	text := strings.Join(strings.Fields(DecodeLatex(rawName)), " ")
+	name := &Name{}
+	if text == "" {
+		return name
+	}
+
+	if strings.Contains(text, ",") {
+		var partList []string
+		for _, part := range strings.Split(text, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				partList = append(partList, part)
+			}
+		}
+
+		switch {
+		case len(partList) == 1:
+			return Parse(partList[0])
+		case len(partList) == 2 && isSuffix(partList[1]):
+			// Given Family, Jr.
+			name = Parse(partList[0])
+			name.Suffix = partList[1]
+			return name
+		case len(partList) >= 3 && isSuffix(partList[1]):
+			// Family, Jr., Given
+			name.Suffix = partList[1]
+			name.Given = strings.Join(partList[2:], " ")
+		default:
+			// Family, Given
+			name.Given = strings.Join(partList[1:], " ")
+		}
+		name.Particle, name.Family = splitParticle(strings.Fields(partList[0]))
+		return name
+	}
+
+	tokenList := strings.Fields(text)
+	if len(tokenList) > 1 && isSuffix(tokenList[len(tokenList)-1]) {
+		name.Suffix = tokenList[len(tokenList)-1]
+		tokenList = tokenList[:len(tokenList)-1]
+	}
+
+	// the family name starts at the first lowercase particle after the given names, or is the last word
+	familyStart := len(tokenList) - 1
+	for i := 1; i < len(tokenList)-1; i++ {
+		if isParticle(tokenList[i]) {
+			familyStart = i
+			break
+		}
+	}
+	name.Given = strings.Join(tokenList[:familyStart], " ")
+	name.Particle, name.Family = splitParticle(tokenList[familyStart:])
+
+	return name
+}
+
+// String returns the display form of the name
+func (n *Name) String() string {
+	var partList []string
+	for _, part := range []string{n.Given, n.Particle, n.Family, n.Suffix} {
+		if part != "" {
+			partList = append(partList, part)
+		}
+	}
+	return strings.Join(partList, " ")
+}
+
+// Key returns the ASCII-folded comparable form of the name (e.g. `Jürgen van der Berg` → `jurgen van der berg`)
+func (n *Name) Key() string {
+	return Key(n.String())
+}
+
+// FamilyKey returns the ASCII-folded comparable form of the family name, with its particles
+func (n *Name) FamilyKey() string {
+	return Key(n.Particle + " " + n.Family)
+}
+
+// GivenKeys returns the ASCII-folded given names (e.g. `Jean-Pierre A.` → [jean pierre a])
+func (n *Name) GivenKeys() []string {
+	return strings.Fields(Key(n.Given))
+}
+
+// Initials returns the initials of the given names (e.g. `Jean-Pierre Alain` → `jpa`)
+func (n *Name) Initials() string {
+	var builder strings.Builder
+	for _, given := range n.GivenKeys() {
+		r, _ := utf8.DecodeRuneInString(given)
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// splitParticle splits the leading lowercase particles from the family name
+func splitParticle(tokenList []string) (string, string) {
+	i := 0
+	for i < len(tokenList)-1 && isParticle(tokenList[i]) {
+		i++
+	}
+	return strings.Join(tokenList[:i], " "), strings.Join(tokenList[i:], " ")
+}
+
+func isParticle(token string) bool {
+	r, _ := utf8.DecodeRuneInString(token)
+	if !unicode.IsLower(r) {
+		return false
+	}
+	_, exists := particles[strings.TrimRight(token, ".'’")]
+	return exists
+}
+
+func isSuffix(token string) bool {
+	_, exists := suffixes[strings.ToLower(strings.ReplaceAll(token, ".", ""))]
+	return exists
+}
@@ -0,0 +1,159 @@
+package ror
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/papetier/scraper/pkg/database"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dumpRecord is a record of a ROR data dump, in the v1 or v2 schema (https://ror.readme.io/docs/data-dump)
+type dumpRecord struct {
+	Id     string   `json:"id"`
+	Status string   `json:"status"`
+	Types  []string `json:"types"`
+
+	// v1 schema
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	Acronyms []string `json:"acronyms"`
+	Labels   []struct {
+		Label string `json:"label"`
+	} `json:"labels"`
+	Country *struct {
+		CountryCode string `json:"country_code"`
+		CountryName string `json:"country_name"`
+	} `json:"country"`
+
+	// v2 schema
+	Names []struct {
+		Value string   `json:"value"`
+		Types []string `json:"types"`
+	} `json:"names"`
+	Locations []struct {
+		GeonamesDetails struct {
+			CountryCode string `json:"country_code"`
+			CountryName string `json:"country_name"`
+		} `json:"geonames_details"`
+	} `json:"locations"`
+}
+
+// readDump streams the organisations of a ROR dump (JSON file, or zip archive as distributed) to the callback
+func readDump(path string, callback func(*database.RorOrganisation) error) error {
+	reader, closeReader, err := openDump(path)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	decoder := json.NewDecoder(reader)
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("reading the start of the dump: %w", err)
+	}
+	if delimiter, isDelimiter := token.(json.Delim); !isDelimiter || delimiter != '[' {
+		return fmt.Errorf("the dump isn't a JSON array")
+	}
+
+	loadedAt := time.Now()
+	for decoder.More() {
+		var record dumpRecord
+		err = decoder.Decode(&record)
+		if err != nil {
+			return fmt.Errorf("decoding a dump record: %w", err)
+		}
+
+		err = callback(record.toRorOrganisation(loadedAt))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func openDump(path string) (io.Reader, func(), error) {
+	if strings.ToLower(filepath.Ext(path)) != ".zip" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening the dump: %w", err)
+		}
+		return file, func() { file.Close() }, nil
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening the dump archive: %w", err)
+	}
+
+	// the archives contain the dump in several formats (and schema versions), the v2 JSON one is preferred
+	var dumpFile *zip.File
+	for _, file := range archive.File {
+		if strings.HasSuffix(file.Name, ".json") && (dumpFile == nil || strings.Contains(file.Name, "schema_v2")) {
+			dumpFile = file
+		}
+	}
+	if dumpFile == nil {
+		archive.Close()
+		return nil, nil, fmt.Errorf("no JSON file in the dump archive")
+	}
+
+	file, err := dumpFile.Open()
+	if err != nil {
+		archive.Close()
+		return nil, nil, fmt.Errorf("opening %s in the dump archive: %w", dumpFile.Name, err)
+	}
+	return file, func() {
+		file.Close()
+		archive.Close()
+	}, nil
+}
+
+func (r *dumpRecord) toRorOrganisation(loadedAt time.Time) *database.RorOrganisation {
+	rorOrganisation := &database.RorOrganisation{
+		RorId:    strings.TrimPrefix(r.Id, "https://ror.org/"),
+		Name:     r.Name,
+		Status:   r.Status,
+		Types:    r.Types,
+		Aliases:  r.Aliases,
+		Acronyms: r.Acronyms,
+		LoadedAt: loadedAt,
+	}
+	for _, label := range r.Labels {
+		rorOrganisation.Labels = append(rorOrganisation.Labels, label.Label)
+	}
+	if r.Country != nil {
+		rorOrganisation.CountryCode = &r.Country.CountryCode
+		rorOrganisation.CountryName = &r.Country.CountryName
+	}
+
+	for _, name := range r.Names {
+		for _, nameType := range name.Types {
+			switch nameType {
+			case "ror_display":
+				rorOrganisation.Name = name.Value
+			case "alias":
+				rorOrganisation.Aliases = append(rorOrganisation.Aliases, name.Value)
+			case "acronym":
+				rorOrganisation.Acronyms = append(rorOrganisation.Acronyms, name.Value)
+			case "label":
+				rorOrganisation.Labels = append(rorOrganisation.Labels, name.Value)
+			}
+		}
+	}
+	if len(r.Locations) > 0 && rorOrganisation.CountryCode == nil {
+		details := r.Locations[0].GeonamesDetails
+		rorOrganisation.CountryCode = &details.CountryCode
+		rorOrganisation.CountryName = &details.CountryName
+	}
+	for i, organisationType := range rorOrganisation.Types {
+		rorOrganisation.Types[i] = strings.ToLower(organisationType)
+	}
+
+	return rorOrganisation
+}
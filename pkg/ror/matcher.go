@@ -0,0 +1,258 @@
+package ror
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/personname"
+	"regexp"
+	"strings"
+)
+
+const (
+	activeStatus       = "active"
+	maxWindowSize      = 12
+	minNameWindowSize  = 2
+	segmentSeparators  = ",;()"
+	exactMatchMethod   = "exact"
+	segmentMatchMethod = "segment"
+	partialMatchMethod = "partial"
+)
+
+var acronymRegexp = regexp.MustCompile(`^[\p{Lu}0-9&\-]{2,}$`)
+
+// countryAliasMap maps the usual forms of the countries, besides the ROR country names, to their code
+var countryAliasMap = map[string]string{
+	"USA": "US", "U.S.A.": "US", "U.S.": "US", "United States of America": "US",
+	"UK": "GB", "U.K.": "GB", "Great Britain": "GB", "England": "GB", "Scotland": "GB", "Wales": "GB", "Northern Ireland": "GB",
+	"PR China": "CN", "P.R. China": "CN", "People's Republic of China": "CN",
+	"Korea": "KR", "South Korea": "KR", "Republic of Korea": "KR",
+	"Netherlands": "NL", "The Netherlands": "NL", "Holland": "NL",
+	"Russia": "RU", "Russian Federation": "RU",
+	"Czech Republic": "CZ", "Czechia": "CZ",
+	"Deutschland": "DE", "Brasil": "BR", "España": "ES", "Italia": "IT",
+}
+
+// Match is an affiliation matched with a ROR organisation
+type Match struct {
+	RorId  string
+	Method string
+}
+
+// indexEntry is a name of a ROR organisation (acronyms are only matched against uppercase words)
+type indexEntry struct {
+	rorOrganisation *database.RorOrganisation
+	isAcronym       bool
+}
+
+// Matcher matches the raw affiliations against the ROR organisations' names, aliases, labels and acronyms
+type Matcher struct {
+	index map[string][]*indexEntry
+	// the country codes by name key (e.g. `united states`, `usa` → `US`), and the uppercase country codes
+	countryIndex   map[string]string
+	countryCodeSet map[string]struct{}
+}
+
+// NewMatcher indexes the ROR organisations by name key (see personname.Key), and their countries
+func NewMatcher(rorOrganisationList []*database.RorOrganisation) *Matcher {
+	matcher := &Matcher{
+		index:          make(map[string][]*indexEntry),
+		countryIndex:   make(map[string]string),
+		countryCodeSet: make(map[string]struct{}),
+	}
+	for alias, countryCode := range countryAliasMap {
+		matcher.addCountry(alias, countryCode)
+	}
+	for _, rorOrganisation := range rorOrganisationList {
+		if rorOrganisation.CountryCode != nil && rorOrganisation.CountryName != nil {
+			matcher.addCountry(*rorOrganisation.CountryName, *rorOrganisation.CountryCode)
+		}
+		nameList := append([]string{rorOrganisation.Name}, rorOrganisation.Aliases...)
+		nameList = append(nameList, rorOrganisation.Labels...)
+		for _, name := range nameList {
+			matcher.add(name, rorOrganisation, false)
+		}
+		for _, acronym := range rorOrganisation.Acronyms {
+			matcher.add(acronym, rorOrganisation, true)
+		}
+	}
+	return matcher
+}
+
+func (m *Matcher) add(name string, rorOrganisation *database.RorOrganisation, isAcronym bool) {
+	key := personname.Key(name)
+	if key == "" {
+		return
+	}
+	for _, entry := range m.index[key] {
+		if entry.rorOrganisation == rorOrganisation {
+			return
+		}
+	}
+	m.index[key] = append(m.index[key], &indexEntry{
+		rorOrganisation: rorOrganisation,
+		isAcronym:       isAcronym,
+	})
+}
+
+func (m *Matcher) addCountry(name string, countryCode string) {
+	countryCode = strings.ToUpper(countryCode)
+	if key := personname.Key(name); key != "" {
+		m.countryIndex[key] = countryCode
+	}
+	m.countryCodeSet[countryCode] = struct{}{}
+}
+
+// Match returns the ROR organisation of an affiliation, nil if none or ambiguous
+// The whole affiliation is tried first, then its segments (e.g. `Dept. of Physics, Stanford University, CA`),
+// then the longest word sequences within the segments (e.g. `MIT CSAIL`)
+// The countries (e.g. `UK`, `USA`) are never matched as organisations, and an organisation only matched by its
+// acronym must be in one of the affiliation's countries (if any is mentioned)
+func (m *Matcher) Match(affiliation string) *Match {
+	affiliationKey := personname.Key(affiliation)
+	segmentList := strings.FieldsFunc(affiliation, func(r rune) bool {
+		return strings.ContainsRune(segmentSeparators, r)
+	})
+	countrySet := m.mentionedCountries(affiliationKey, segmentList)
+
+	if rorId := m.pick(m.lookup(affiliation, countrySet), affiliationKey); rorId != "" {
+		return &Match{RorId: rorId, Method: exactMatchMethod}
+	}
+
+	for _, segment := range segmentList {
+		if rorId := m.pick(m.lookup(segment, countrySet), affiliationKey); rorId != "" {
+			return &Match{RorId: rorId, Method: segmentMatchMethod}
+		}
+	}
+
+	for _, segment := range segmentList {
+		wordList := strings.Fields(segment)
+		for size := minInt(len(wordList), maxWindowSize); size >= 1; size-- {
+			var candidateList []*database.RorOrganisation
+			for start := 0; start+size <= len(wordList); start++ {
+				window := strings.Join(wordList[start:start+size], " ")
+				if size < minNameWindowSize && !acronymRegexp.MatchString(strings.Trim(window, ".")) {
+					continue
+				}
+				candidateList = append(candidateList, m.lookup(window, countrySet)...)
+			}
+			if rorId := m.pick(candidateList, affiliationKey); rorId != "" {
+				return &Match{RorId: rorId, Method: partialMatchMethod}
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the ROR organisations having the text as name (or acronym, if written in uppercase and in one of the
+// affiliation's countries), none if the text is a country
+func (m *Matcher) lookup(text string, countrySet map[string]struct{}) []*database.RorOrganisation {
+	text = strings.TrimSpace(text)
+	if m.isCountry(text) {
+		return nil
+	}
+	isUppercase := acronymRegexp.MatchString(strings.ReplaceAll(strings.Trim(text, "."), " ", ""))
+
+	var rorOrganisationList []*database.RorOrganisation
+	for _, entry := range m.index[personname.Key(text)] {
+		if entry.isAcronym && (!isUppercase || !isInCountries(entry.rorOrganisation, countrySet)) {
+			continue
+		}
+		rorOrganisationList = append(rorOrganisationList, entry.rorOrganisation)
+	}
+	return rorOrganisationList
+}
+
+// pick returns the ROR id of the only candidate, preferring the active ones and the ones whose country is in the affiliation
+func (m *Matcher) pick(candidateList []*database.RorOrganisation, affiliationKey string) string {
+	candidateList = uniqueOrganisations(candidateList)
+	if len(candidateList) > 1 {
+		candidateList = filterOrganisations(candidateList, func(rorOrganisation *database.RorOrganisation) bool {
+			return rorOrganisation.Status == activeStatus
+		})
+	}
+	if len(candidateList) > 1 {
+		candidateList = filterOrganisations(candidateList, func(rorOrganisation *database.RorOrganisation) bool {
+			return rorOrganisation.CountryName != nil && containsWords(affiliationKey, personname.Key(*rorOrganisation.CountryName))
+		})
+	}
+	if len(candidateList) != 1 {
+		return ""
+	}
+	return candidateList[0].RorId
+}
+
+// isCountry returns whether the text is a country name, usual form or uppercase code (e.g. `France`, `UK`, `CA`)
+func (m *Matcher) isCountry(text string) bool {
+	if _, exists := m.countryIndex[personname.Key(text)]; exists {
+		return true
+	}
+	_, exists := m.countryCodeSet[strings.Trim(text, ".")]
+	return exists
+}
+
+// mentionedCountries returns the codes of the countries in the affiliation: the country names (and usual forms) within
+// it, and its segments which are country codes
+func (m *Matcher) mentionedCountries(affiliationKey string, segmentList []string) map[string]struct{} {
+	countrySet := make(map[string]struct{})
+	for countryKey, countryCode := range m.countryIndex {
+		if containsWords(affiliationKey, countryKey) {
+			countrySet[countryCode] = struct{}{}
+		}
+	}
+	for _, segment := range segmentList {
+		countryCode := strings.Trim(strings.TrimSpace(segment), ".")
+		if _, exists := m.countryCodeSet[countryCode]; exists {
+			countrySet[countryCode] = struct{}{}
+		}
+	}
+	return countrySet
+}
+
+// isInCountries returns whether the organisation is in one of the countries, or if the country is unknown or none is given
+func isInCountries(rorOrganisation *database.RorOrganisation, countrySet map[string]struct{}) bool {
+	if len(countrySet) == 0 || rorOrganisation.CountryCode == nil {
+		return true
+	}
+	_, exists := countrySet[strings.ToUpper(*rorOrganisation.CountryCode)]
+	return exists
+}
+
+func uniqueOrganisations(rorOrganisationList []*database.RorOrganisation) []*database.RorOrganisation {
+	var uniqueList []*database.RorOrganisation
+	seen := make(map[string]struct{})
+	for _, rorOrganisation := range rorOrganisationList {
+		if _, exists := seen[rorOrganisation.RorId]; !exists {
+			seen[rorOrganisation.RorId] = struct{}{}
+			uniqueList = append(uniqueList, rorOrganisation)
+		}
+	}
+	return uniqueList
+}
+
+// filterOrganisations keeps the organisations satisfying the predicate, unless none does
+func filterOrganisations(rorOrganisationList []*database.RorOrganisation, predicate func(*database.RorOrganisation) bool) []*database.RorOrganisation {
+	var filteredList []*database.RorOrganisation
+	for _, rorOrganisation := range rorOrganisationList {
+		if predicate(rorOrganisation) {
+			filteredList = append(filteredList, rorOrganisation)
+		}
+	}
+	if len(filteredList) == 0 {
+		return rorOrganisationList
+	}
+	return filteredList
+}
+
+func containsWords(key string, words string) bool {
+	if words == "" {
+		return false
+	}
+	return strings.Contains(" "+key+" ", " "+words+" ")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
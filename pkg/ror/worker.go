@@ -0,0 +1,97 @@
+package ror
+
+import (
+	"fmt"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	loadBatchSize  = 1000
+	matchBatchSize = 500
+)
+
+// LoadDump upserts the organisations of a local ROR data dump, and marks the unmatched organisations as to match again
+func LoadDump(path string) error {
+	log.Infof("loading the ROR dump %s", path)
+
+	loadedCount := 0
+	var batch []*database.RorOrganisation
+	err := readDump(path, func(rorOrganisation *database.RorOrganisation) error {
+		batch = append(batch, rorOrganisation)
+		if len(batch) < loadBatchSize {
+			return nil
+		}
+		err := database.SaveRorOrganisations(batch)
+		if err != nil {
+			return err
+		}
+		loadedCount += len(batch)
+		batch = nil
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading the ROR dump: %w", err)
+	}
+
+	err = database.SaveRorOrganisations(batch)
+	if err != nil {
+		return err
+	}
+	loadedCount += len(batch)
+
+	resetCount, err := database.ResetRorMatches()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("ROR dump loaded: %d organisations, %d unmatched organisations to match again", loadedCount, resetCount)
+	return nil
+}
+
+// MatchOrganisations links the organisations not matched yet to the loaded ROR organisations
+// The raw affiliation (organisation name) is kept as is, for provenance
+func MatchOrganisations() error {
+	rorOrganisationList, err := database.GetRorOrganisations()
+	if err != nil {
+		return err
+	}
+	if len(rorOrganisationList) == 0 {
+		return fmt.Errorf("no ROR organisation loaded (see the `ror load` command)")
+	}
+	matcher := NewMatcher(rorOrganisationList)
+
+	matchedCount := 0
+	unmatchedCount := 0
+	var lastId database.ID
+	for {
+		organisationList, err := database.GetOrganisationsToMatch(lastId, matchBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(organisationList) == 0 {
+			break
+		}
+
+		for _, organisation := range organisationList {
+			lastId = organisation.Id
+
+			var rorId, method *string
+			if match := matcher.Match(organisation.Name); match != nil {
+				rorId = &match.RorId
+				method = &match.Method
+				matchedCount++
+			} else {
+				unmatchedCount++
+			}
+
+			err = organisation.SaveRorMatch(rorId, method)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("ROR matching done: %d organisations matched, %d unmatched", matchedCount, unmatchedCount)
+	return nil
+}
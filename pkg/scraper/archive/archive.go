@@ -0,0 +1,170 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gocolly/colly/v2"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const responsesTable = "archived_responses"
+
+// Archive keeps every fetched response: the body in a content-addressed store and its metadata in PostgreSQL
+type Archive struct {
+	pool           *pgxpool.Pool
+	Namespace      string
+	ResponsesTable string
+	Store          *FileStore
+}
+
+// Response is an archived response's metadata
+type Response struct {
+	Url         string
+	StatusCode  int
+	Headers     http.Header
+	ContentKey  string
+	ContentSize int
+	FetchedAt   time.Time
+}
+
+var once sync.Once
+
+func NewArchive(namespace string) *Archive {
+	return &Archive{
+		pool:           database.GetPool(),
+		Namespace:      namespace,
+		ResponsesTable: responsesTable,
+		Store:          NewFileStore(config.Archive.Path),
+	}
+}
+
+func prepareDB(a *Archive) {
+	once.Do(func() {
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id bigserial PRIMARY KEY,
+			namespace text NOT NULL,
+			url text NOT NULL,
+			status_code integer NOT NULL,
+			headers jsonb NOT NULL,
+			content_key text NOT NULL,
+			content_size bigint NOT NULL,
+			fetched_at timestamptz NOT NULL DEFAULT now()
+		);`, a.ResponsesTable)
+		_, err := a.pool.Exec(context.Background(), query)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		query = fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_namespace_url_idx ON %s (namespace, url, fetched_at);`, a.ResponsesTable, a.ResponsesTable)
+		_, err = a.pool.Exec(context.Background(), query)
+		if err != nil {
+			log.Fatal(err)
+		}
+	})
+}
+
+// Init initializes the archive's table
+func (a *Archive) Init() {
+	prepareDB(a)
+}
+
+// OnResponse returns a colly callback archiving every response
+func (a *Archive) OnResponse() func(r *colly.Response) {
+	return func(r *colly.Response) {
+		var headers http.Header
+		if r.Headers != nil {
+			headers = *r.Headers
+		}
+		err := a.Save(r.Request.URL.String(), r.StatusCode, headers, r.Body)
+		if err != nil {
+			log.Errorf("archiving the response of %s: %s", r.Request.URL, err)
+		}
+	}
+}
+
+// Save stores the body and records the response's metadata
+func (a *Archive) Save(url string, statusCode int, headers http.Header, body []byte) error {
+	contentKey, err := a.Store.Put(body)
+	if err != nil {
+		return fmt.Errorf("storing the body: %w", err)
+	}
+
+	headersJson, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("encoding the headers: %w", err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (namespace, url, status_code, headers, content_key, content_size, fetched_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`, a.ResponsesTable)
+	_, err = a.pool.Exec(context.Background(), query, a.Namespace, url, statusCode, headersJson, contentKey, len(body), time.Now())
+	if err != nil {
+		return fmt.Errorf("inserting the archived response metadata: %w", err)
+	}
+
+	log.Debugf("archived response of %s (%s)", url, contentKey)
+	return nil
+}
+
+// GetLatest returns the latest archived response for the URL with its body (nil if none was archived)
+func (a *Archive) GetLatest(url string) (*Response, []byte, error) {
+	query := fmt.Sprintf(`SELECT url, status_code, headers, content_key, content_size, fetched_at FROM %s WHERE namespace = $1 AND url = $2 ORDER BY fetched_at DESC LIMIT 1`, a.ResponsesTable)
+
+	rows, err := a.pool.Query(context.Background(), query, a.Namespace, url)
+	defer rows.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching the archived response of %s: %w", url, err)
+	}
+
+	var response *Response
+	for rows.Next() {
+		response = &Response{}
+		var headersJson []byte
+		err = rows.Scan(&response.Url, &response.StatusCode, &headersJson, &response.ContentKey, &response.ContentSize, &response.FetchedAt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scanning the archived response of %s: %w", url, err)
+		}
+		err = json.Unmarshal(headersJson, &response.Headers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding the archived headers of %s: %w", url, err)
+		}
+	}
+	if response == nil {
+		return nil, nil, nil
+	}
+
+	body, err := a.Store.Get(response.ContentKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the archived body of %s: %w", url, err)
+	}
+
+	return response, body, nil
+}
+
+// ListUrls returns the archived URLs ordered by first fetch
+func (a *Archive) ListUrls() ([]string, error) {
+	query := fmt.Sprintf(`SELECT url FROM %s WHERE namespace = $1 GROUP BY url ORDER BY min(fetched_at)`, a.ResponsesTable)
+
+	rows, err := a.pool.Query(context.Background(), query, a.Namespace)
+	defer rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("listing the archived URLs: %w", err)
+	}
+
+	var urlList []string
+	for rows.Next() {
+		var url string
+		err = rows.Scan(&url)
+		if err != nil {
+			return nil, fmt.Errorf("scanning the archived URL: %w", err)
+		}
+		urlList = append(urlList, url)
+	}
+
+	return urlList, nil
+}
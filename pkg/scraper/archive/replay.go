@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// ReplayTransport is an http.RoundTripper answering requests with the archived responses, without network access
+type ReplayTransport struct {
+	Archive *Archive
+}
+
+func NewReplayTransport(archive *Archive) *ReplayTransport {
+	return &ReplayTransport{
+		Archive: archive,
+	}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	archivedResponse, body, err := t.Archive.GetLatest(url)
+	if err != nil {
+		return nil, err
+	}
+	if archivedResponse == nil {
+		return nil, fmt.Errorf("no archived response for %s", url)
+	}
+
+	headers := archivedResponse.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	// the stored body is always decompressed
+	headers.Del("Content-Encoding")
+	headers.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return &http.Response{
+		Status:        strconv.Itoa(archivedResponse.StatusCode) + " " + http.StatusText(archivedResponse.StatusCode),
+		StatusCode:    archivedResponse.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const contentFileExtension = ".gz"
+
+// FileStore is a content-addressed store keeping gzipped contents on the filesystem
+// Contents are stored under `<root>/<first 2 chars of key>/<key>.gz`, the key being the SHA-256 of the raw content
+type FileStore struct {
+	Root string
+}
+
+func NewFileStore(root string) *FileStore {
+	return &FileStore{
+		Root: root,
+	}
+}
+
+// ContentKey returns the content-addressed key of a raw content
+func ContentKey(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// Put compresses and writes the content (if not already stored) and returns its key
+func (f *FileStore) Put(content []byte) (string, error) {
+	key := ContentKey(content)
+	contentPath := f.path(key)
+
+	// already stored
+	if _, err := os.Stat(contentPath); err == nil {
+		return key, nil
+	}
+
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, err := gzipWriter.Write(content)
+	if err != nil {
+		return "", fmt.Errorf("compressing the content %s: %w", key, err)
+	}
+	err = gzipWriter.Close()
+	if err != nil {
+		return "", fmt.Errorf("compressing the content %s: %w", key, err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(contentPath), 0755)
+	if err != nil {
+		return "", fmt.Errorf("creating the archive directory for %s: %w", key, err)
+	}
+
+	// write to a temporary file first so that a content file is never partially written
+	tmpFile, err := ioutil.TempFile(filepath.Dir(contentPath), key+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("creating the temporary file for %s: %w", key, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(compressed.Bytes())
+	if err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("writing the content %s: %w", key, err)
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("closing the temporary file for %s: %w", key, err)
+	}
+
+	err = os.Rename(tmpFile.Name(), contentPath)
+	if err != nil {
+		return "", fmt.Errorf("moving the content %s into the archive: %w", key, err)
+	}
+
+	return key, nil
+}
+
+// Get reads and decompresses the content stored under the key
+func (f *FileStore) Get(key string) ([]byte, error) {
+	file, err := os.Open(f.path(key))
+	if err != nil {
+		return nil, fmt.Errorf("opening the content %s: %w", key, err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing the content %s: %w", key, err)
+	}
+	defer gzipReader.Close()
+
+	content, err := ioutil.ReadAll(gzipReader)
+	if err != nil {
+		return nil, fmt.Errorf("reading the content %s: %w", key, err)
+	}
+
+	return content, nil
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.Root, key[:2], key+contentFileExtension)
+}
@@ -3,8 +3,16 @@ package arxiv
 import (
 	"github.com/antchfx/xmlquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/arxivid"
+	"github.com/papetier/scraper/pkg/classification"
 	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/doi"
+	"github.com/papetier/scraper/pkg/identifier"
+	"github.com/papetier/scraper/pkg/journalref"
+	"github.com/papetier/scraper/pkg/license"
+	"github.com/papetier/scraper/pkg/paperlink"
+	"github.com/papetier/scraper/pkg/papertext"
 	"github.com/papetier/scraper/pkg/scraper/collector"
 	log "github.com/sirupsen/logrus"
 	"strconv"
@@ -13,15 +21,17 @@ import (
 )
 
 const (
-	arxivAbstractUrl = arxivBaseUrl + "abs/"
-	arxivPdfUrl      = arxivBaseUrl + "pdf/"
-	arxivBaseUrl     = "http://arxiv.org/"
 	arxivErrorTitle  = "Error"
+	arxivApiQueryUrl = "http://export.arxiv.org/api/query"
+	identifierSource = "arxiv"
 )
 
-func SetupCollector(c *colly.Collector) {
-	c.OnXML("/feed", feedParser)
-	c.OnXML("/feed/entry", entryParser)
+func SetupCollector(wc *collector.WebsiteCollector) {
+	// the search and id_list results change (new and updated eprints), they're never served from the cache's TTL
+	wc.RevalidateUrlPrefix(arxivApiQueryUrl)
+
+	wc.Collector.OnXML("/feed", feedParser)
+	wc.Collector.OnXML("/feed/entry", entryParser)
 }
 
 func VisitInitUrlList(wc *collector.WebsiteCollector) {
@@ -31,8 +41,11 @@ func VisitInitUrlList(wc *collector.WebsiteCollector) {
 }
 
 func feedParser(e *colly.XMLElement) {
-	// get category code
+	// get category code (none for the id_list queries)
 	feedTitle := e.ChildText("title")
+	if isIdListFeedTitle(feedTitle) {
+		return
+	}
 	categoryCode := getCategoryCodeFromSearchFeedTitle(feedTitle)
 	if categoryCode == nil {
 		log.Errorf("no category found in feed title %s", feedTitle)
@@ -48,6 +61,9 @@ func feedParser(e *colly.XMLElement) {
 	} else {
 		isLastResultEmptyByCategoryCode[*categoryCode] = false
 	}
+
+	// track the entries' update dates (for the refresh)
+	trackUpdatedDates(*categoryCode, e)
 }
 
 func entryParser(e *colly.XMLElement) {
@@ -68,33 +84,46 @@ func entryParser(e *colly.XMLElement) {
 		Paper: paper,
 	}
 
-	// parse id
+	// parse id (with version)
 	id := strings.TrimSpace(e.ChildText("id"))
-	idParsingResult := strings.Split(id, arxivAbstractUrl)
-	if len(idParsingResult) < 2 {
+	arxivId, err := arxivid.Parse(id)
+	if err != nil {
 		log.Errorf("unexpected arxiv id format: %s", id)
 		handleErrorEntry(e)
 		return
-	} else {
-		arxivId := idParsingResult[1]
-		log.Debugf("parsing entry element %s", arxivId)
-		arxivEprint.ArxivId = arxivId
 	}
+	log.Debugf("parsing entry element %s", arxivId)
+	arxivEprint.ArxivId = arxivId.Base
+	arxivEprint.LatestVersion = arxivId.Version
+	recordFetchedId(e.Request, arxivId)
+	paper.AddIdentifier(identifier.Arxiv, arxivId.Base, identifierSource)
 
-	// parse doi
-	doi := strings.TrimSpace(e.ChildText("arxiv:doi"))
-	if doi != "" {
-		paper.Doi = &doi
+	// parse doi (possibly several, or as URLs)
+	rawDoi := strings.TrimSpace(e.ChildText("arxiv:doi"))
+	if rawDoi != "" {
+		doiList := doi.Parse(rawDoi)
+		if len(doiList) > 0 {
+			paper.Doi = &doiList[0]
+			for _, paperDoi := range doiList {
+				paper.AddIdentifier(identifier.Doi, paperDoi, identifierSource)
+			}
+		} else {
+			log.Warnf("no valid DOI in `%s` (arXiv's eprint %s)", rawDoi, arxivEprint.ArxivId)
+		}
 	}
 
 	// parse abstract
 	abstract := strings.TrimSpace(e.ChildText("summary"))
 	paper.Abstract = abstract
 
+	// normalise title and abstract (display and search versions)
+	papertext.Normalise(paper)
+
 	// parse journal_ref
 	journalRef := strings.TrimSpace(e.ChildText("arxiv:journal_ref"))
 	if journalRef != "" {
 		paper.JournalRef = &journalRef
+		journalref.Parse(journalRef).ApplyTo(paper)
 	}
 
 	// TODO: parse year
@@ -103,20 +132,18 @@ func entryParser(e *colly.XMLElement) {
 	var authorList []*database.Author
 	authorIndex := 1
 	for {
-		authorName, authorAffiliation := getAuthorNameAndAffiliation(e, authorIndex)
+		authorName, authorAffiliationList := getAuthorNameAndAffiliations(e, authorIndex)
 		if authorName == "" {
 			break
 		}
 
-		author := &database.Author{
-			FullName: authorName,
-		}
+		author := database.NewAuthor(authorName)
 
-		if authorAffiliation != "" {
+		for _, authorAffiliation := range authorAffiliationList {
 			organisation := &database.Organisation{
 				Name: authorAffiliation,
 			}
-			author.Organisations = []*database.Organisation{organisation}
+			author.Organisations = append(author.Organisations, organisation)
 		}
 
 		authorList = append(authorList, author)
@@ -130,11 +157,43 @@ func entryParser(e *colly.XMLElement) {
 	comment := strings.TrimSpace(e.ChildText("arxiv:comment"))
 	if comment != "" {
 		arxivEprint.Comment = &comment
+		analyseComment(comment).applyTo(arxivEprint)
+	}
+
+	// parse the code, dataset, project page and model links
+	for _, paperLink := range paperlink.Parse(abstract, database.AbstractLinkSource) {
+		paper.AddLink(paperLink)
+	}
+	for _, paperLink := range paperlink.Parse(comment, database.ArxivCommentLinkSource) {
+		paper.AddLink(paperLink)
+	}
+
+	// parse withdrawal (notice in the comment of the latest version)
+	arxivEprint.IsWithdrawn, arxivEprint.WithdrawalReason = parseWithdrawal(comment)
+
+	// parse report numbers
+	reportNumbers := strings.TrimSpace(e.ChildText("arxiv:report_no"))
+	if reportNumbers != "" {
+		arxivEprint.ReportNumbers = parseReportNumbers(reportNumbers)
+	}
+
+	// parse license (link or arXiv's element)
+	licenseUrl := strings.TrimSpace(e.ChildAttr("link[@rel='license']", "href"))
+	if licenseUrl == "" {
+		licenseUrl = strings.TrimSpace(e.ChildText("arxiv:license"))
+	}
+	if licenseUrl != "" {
+		arxivEprint.License = &licenseUrl
+		if licenseId, isKnown := license.Normalise(licenseUrl); isKnown {
+			arxivEprint.LicenseId = &licenseId
+		} else {
+			log.Warnf("unknown license `%s` (arXiv's eprint %s)", licenseUrl, arxivEprint.ArxivId)
+		}
 	}
 
 	// parse pdf_link (if different from default)
 	pdfLink := strings.TrimSpace(e.ChildAttr("link[@title='pdf']", "href"))
-	if pdfLink != arxivPdfUrl+arxivEprint.ArxivId {
+	if pdfId, err := arxivid.Parse(pdfLink); err != nil || *pdfId != *arxivId {
 		arxivEprint.PdfLink = &pdfLink
 	}
 
@@ -154,50 +213,63 @@ func entryParser(e *colly.XMLElement) {
 	}
 	arxivEprint.UpdatedAt = updatedAt
 
-	// parse latest_version
-	versionSplitResult := strings.Split(arxivEprint.ArxivId, "v")
-	if len(versionSplitResult) < 2 {
-		log.Errorf("unexpected arxiv id format for version parsing: %s", id)
-	} else {
-		latestVersionString := versionSplitResult[len(versionSplitResult)-1]
-		latestVersion, err := strconv.Atoi(latestVersionString)
-		if err != nil {
-			log.Errorf("formatting latest version (%s): %s", latestVersionString, err)
-		}
-		arxivEprint.LatestVersion = latestVersion
-	}
-
-	// parse categories (with primary) + extra categories
+	// parse categories (with primary) and classification codes (e.g. MSC, ACM), the unknown categories are registered
+	// as provisional (the other terms are skipped)
 	var otherArxivCategories []*database.ArxivCategory
-	var extraCategories []string
+	classificationCodeSet := make(map[string]struct{})
 	primaryCategoryCode := strings.TrimSpace(e.ChildAttr("arxiv:primary_category", "term"))
 	categoryCodeList := e.ChildAttrs("category", "term")
+	if primaryCategoryCode != "" {
+		categoryCodeList = append([]string{primaryCategoryCode}, categoryCodeList...)
+	}
+	categorySet := make(map[database.ID]struct{})
 	for _, categoryCodeRaw := range categoryCodeList {
 		categoryCode := strings.TrimSpace(categoryCodeRaw)
-		if arxivCategory, exists := categoriesByCodeMap[categoryCode]; exists {
-			if categoryCode == primaryCategoryCode {
-				arxivEprint.PrimaryArxivCategory = arxivCategory
-			} else {
-				otherArxivCategories = append(otherArxivCategories, arxivCategory)
+		if classificationCodeList := classification.Parse(categoryCode); len(classificationCodeList) > 0 {
+			for _, classificationCode := range classificationCodeList {
+				if _, exists := classificationCodeSet[classificationCode.Scheme+classificationCode.Code]; !exists {
+					arxivEprint.ClassificationCodes = append(arxivEprint.ClassificationCodes, classificationCode)
+					classificationCodeSet[classificationCode.Scheme+classificationCode.Code] = struct{}{}
+				}
 			}
+			continue
+		}
+		if !isCategoryCode(categoryCode) {
+			log.Warnf("skipping the category `%s` of arXiv's eprint %s: not an arXiv category code", categoryCode, arxivEprint.ArxivId)
+			continue
+		}
+		arxivCategory, err := getOrRegisterCategory(categoryCode)
+		if err != nil {
+			log.Errorf("registering the category of arXiv's eprint %s: %s", arxivEprint.ArxivId, err)
+			continue
+		}
+		if _, exists := categorySet[arxivCategory.Id]; exists {
+			continue
+		}
+		categorySet[arxivCategory.Id] = struct{}{}
+
+		if categoryCode == primaryCategoryCode {
+			arxivEprint.PrimaryArxivCategory = arxivCategory
 		} else {
-			extraCategories = append(extraCategories, categoryCode)
+			otherArxivCategories = append(otherArxivCategories, arxivCategory)
 		}
 	}
 	arxivEprint.OtherArxivCategories = otherArxivCategories
 
-	// parse extra (atm: only extra categories)
-	if len(extraCategories) > 0 {
-		arxivEprint.Extra = &map[string]interface{}{
-			"categories": extraCategories,
+	isDuplicate, err := arxivEprint.SaveWithPaperAuthorsAndCategories()
+	if isDuplicate {
+		if isReplayRequest(e.Request) {
+			// reparsed from the archive: the same version is saved again with the current parsers
+			_, err = arxivEprint.ReplaceWithPaperAndCategories()
+		} else {
+			// the eprint may have been revised since (e.g. new version, journal reference)
+			_, err = arxivEprint.UpdateWithPaperAndCategories()
 		}
 	}
-
-	isDuplicate, err := arxivEprint.SaveWithPaperAuthorsAndCategories()
 	if canonicalCategoryCode != nil {
 		if isDuplicate {
 			duplicatedPaperCounterByCategoryCode[*canonicalCategoryCode]++
-			log.Warnf("arXiv's eprint %s was already saved, skipping", arxivEprint.ArxivId)
+			log.Warnf("arXiv's eprint %s was already saved", arxivEprint.ArxivId)
 		} else {
 			duplicatedPaperCounterByCategoryCode[*canonicalCategoryCode] = 0
 		}
@@ -207,14 +279,27 @@ func entryParser(e *colly.XMLElement) {
 	}
 }
 
-func getAuthorNameAndAffiliation(e *colly.XMLElement, authorIndex int) (string, string) {
+// getAuthorNameAndAffiliations returns the author's name and affiliations (in order, without duplicates)
+func getAuthorNameAndAffiliations(e *colly.XMLElement, authorIndex int) (string, []string) {
 	xpathQuery := "author[" + strconv.Itoa(authorIndex) + "]"
 	name := e.ChildText(xpathQuery + "/name")
-	affiliation := e.ChildText(xpathQuery + "/arxiv:affiliation")
-	return strings.TrimSpace(name), strings.TrimSpace(affiliation)
+
+	var affiliationList []string
+	affiliationSet := make(map[string]struct{})
+	for _, affiliation := range e.ChildTexts(xpathQuery + "/arxiv:affiliation") {
+		affiliation = strings.TrimSpace(affiliation)
+		if _, exists := affiliationSet[affiliation]; affiliation == "" || exists {
+			continue
+		}
+		affiliationList = append(affiliationList, affiliation)
+		affiliationSet[affiliation] = struct{}{}
+	}
+
+	return strings.TrimSpace(name), affiliationList
 }
 
 func handleErrorEntry(e *colly.XMLElement) {
-	summary := e.ChildText("summary")
+	summary := strings.TrimSpace(e.ChildText("summary"))
 	log.Errorf("the query URL was malformed and the arXiv's API answered with an error: %s", summary)
+	recordFetchError(e.Request, summary)
 }
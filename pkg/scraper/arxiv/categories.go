@@ -1,35 +1,151 @@
 package arxiv
 
 import (
+	"fmt"
 	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
 	"github.com/papetier/scraper/pkg/scraper/collector"
 	log "github.com/sirupsen/logrus"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const (
 	arxivCategoryTaxonomyUrl = "https://arxiv.org/category_taxonomy"
 	arxivPattern             = `^(.+)\((.+)\)$`
+	// the shape of the arXiv category codes (e.g. `cs.LG`, `hep-th`, `cond-mat.str-el`)
+	categoryCodePattern = `^[a-z\-]+(?:\.[A-Za-z\-]+)?$`
 )
 
 var categoriesByCodeMap map[string]*database.ArxivCategory
 var arxivCategoryNameRegexp = regexp.MustCompile(arxivPattern)
+var categoryCodeRegexp = regexp.MustCompile(categoryCodePattern)
 
+// UpdateAndLoadCategories loads the categories: from the database if synced recently (see ARXIV_TAXONOMY_MAX_AGE),
+// else from the live taxonomy page (synced into the database), falling back to the database then to the bundled
+// snapshot when the page can't be fetched or parsed
 func UpdateAndLoadCategories(website *database.Website) error {
 	log.Info("loading categories")
 
-	// prepare collector
+	// recent sync
+	if config.Arxiv.TaxonomyMaxAge > 0 {
+		lastSyncedAt, err := database.GetLastArxivTaxonomySyncedAt()
+		if err != nil {
+			log.Warnf("checking the last taxonomy sync: %s", err)
+		} else if lastSyncedAt != nil && time.Since(*lastSyncedAt) < config.Arxiv.TaxonomyMaxAge {
+			err = loadCategoriesFromDatabase()
+			if err == nil {
+				log.Infof("categories loaded from the database (synced at %s)", lastSyncedAt.Format(time.RFC3339))
+				return nil
+			}
+			log.Warnf("loading the categories from the database: %s", err)
+		}
+	}
+
+	// live taxonomy
 	wc := collector.GetWebsiteCollector(website, colly.AllowURLRevisit())
-	wc.Collector.OnHTML("#category_taxonomy_list", categoriesParser)
+	err := loadCategories(wc)
+	wc.Stats.Log(website.Name + " taxonomy")
+	if err == nil {
+		return nil
+	}
+	log.Warnf("loading the live taxonomy: %s", err)
+
+	// fallbacks
+	err = loadCategoriesFromDatabase()
+	if err == nil {
+		log.Info("categories loaded from the database")
+		return nil
+	}
+	log.Warnf("loading the categories from the database: %s", err)
+
+	return loadSnapshotCategories()
+}
+
+// loadCategories reads the live taxonomy page and syncs its categories into the database
+func loadCategories(wc *collector.WebsiteCollector) error {
+	groupList, err := readTaxonomy(wc)
+	if err != nil {
+		return err
+	}
+
+	return saveCategories(groupList, database.LiveTaxonomySource, nil)
+}
+
+// loadArchivedCategories reads the archived taxonomy page (i.e. replayed), without syncing it into the database: its
+// categories are loaded with their saved ids (the ones never saved are registered as provisional when used)
+func loadArchivedCategories(wc *collector.WebsiteCollector) error {
+	groupList, err := readTaxonomy(wc)
+	if err != nil {
+		return err
+	}
+
+	savedCategoryList, err := database.GetArxivCategoriesWithDeprecated()
+	if err != nil {
+		return fmt.Errorf("loading the saved categories: %w", err)
+	}
+	savedCategoriesByCodeMap := make(map[string]*database.ArxivCategory)
+	for _, category := range savedCategoryList {
+		savedCategoriesByCodeMap[category.OriginalArxivCategoryCode] = category
+	}
+
+	categoriesByCodeMap = make(map[string]*database.ArxivCategory)
+	for _, group := range groupList {
+		for _, archive := range group.ArxivArchives {
+			for _, category := range archive.ArxivCategories {
+				if savedCategory, exists := savedCategoriesByCodeMap[category.OriginalArxivCategoryCode]; exists {
+					categoriesByCodeMap[category.OriginalArxivCategoryCode] = savedCategory
+				} else {
+					log.Warnf("the archived arXiv's category %s was never saved", category.OriginalArxivCategoryCode)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readTaxonomy reads and parses the taxonomy page (live or archived)
+func readTaxonomy(wc *collector.WebsiteCollector) ([]*database.ArxivGroup, error) {
+	var groupList []*database.ArxivGroup
+	var parseErr error
+	wc.Collector.OnHTML("#category_taxonomy_list", func(e *colly.HTMLElement) {
+		groupList, parseErr = categoriesParser(e)
+	})
 
 	// read the categories
 	wc.AddUrl(arxivCategoryTaxonomyUrl)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parsing the taxonomy: %w", parseErr)
+	}
+	if len(groupList) == 0 {
+		return nil, fmt.Errorf("no taxonomy found at %s", arxivCategoryTaxonomyUrl)
+	}
+
+	return groupList, nil
+}
+
+// loadCategoriesFromDatabase loads the saved categories still in the taxonomy
+func loadCategoriesFromDatabase() error {
+	categoryList, err := database.GetArxivCategories()
+	if err != nil {
+		return err
+	}
+	if len(categoryList) == 0 {
+		return fmt.Errorf("no category saved")
+	}
+
+	categoriesByCodeMap = make(map[string]*database.ArxivCategory)
+	for _, category := range categoryList {
+		categoriesByCodeMap[category.OriginalArxivCategoryCode] = category
+	}
 	return nil
 }
 
-func categoriesParser(e *colly.HTMLElement) {
+func categoriesParser(e *colly.HTMLElement) ([]*database.ArxivGroup, error) {
+	var parseErr error
+
 	// get the groups
 	groupNameList := e.ChildTexts("h2")
 	var arxivGroupList []*database.ArxivGroup
@@ -54,7 +170,8 @@ func categoriesParser(e *colly.HTMLElement) {
 			if archiveFullName != "" {
 				result := arxivCategoryNameRegexp.FindStringSubmatch(archiveFullName)
 				if len(result) < 3 {
-					log.Fatalf("error when extracting the archive's code and name from `%s`", archiveFullName)
+					parseErr = fmt.Errorf("extracting the archive's code and name from `%s`", archiveFullName)
+					return
 				}
 				archiveName := result[1]
 				archiveCode := result[2]
@@ -76,7 +193,8 @@ func categoriesParser(e *colly.HTMLElement) {
 				if categoryFullName != "" {
 					result := arxivCategoryNameRegexp.FindStringSubmatch(categoryFullName)
 					if len(result) < 3 {
-						log.Fatalf("error when extracting the category's code and name from `%s`", categoryFullName)
+						parseErr = fmt.Errorf("extracting the category's code and name from `%s`", categoryFullName)
+						return
 					}
 					categoryName := result[2]
 					categoryCode := result[1]
@@ -103,21 +221,80 @@ func categoriesParser(e *colly.HTMLElement) {
 		arxivGroupList[groupIndex].ArxivArchives = arxivArchiveList
 	})
 
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return arxivGroupList, nil
+}
+
+// saveCategories syncs the taxonomy's categories into the database, then loads them
+func saveCategories(arxivGroupList []*database.ArxivGroup, source string, version *string) error {
 	// build the category map + list
-	categoriesByCodeMap = make(map[string]*database.ArxivCategory)
+	newCategoriesByCodeMap := make(map[string]*database.ArxivCategory)
+	var arxivCategoryList []*database.ArxivCategory
 	for _, group := range arxivGroupList {
 		for _, archive := range group.ArxivArchives {
 			for _, category := range archive.ArxivCategories {
-				categoriesByCodeMap[category.OriginalArxivCategoryCode] = category
+				newCategoriesByCodeMap[category.OriginalArxivCategoryCode] = category
+				arxivCategoryList = append(arxivCategoryList, category)
 			}
 		}
 	}
+	if len(arxivCategoryList) == 0 {
+		return fmt.Errorf("no category in the %s taxonomy", source)
+	}
+
+	// sync the categories in db
+	changes, err := database.SaveArxivGroupsArchivesAndCategories(arxivGroupList)
+	if err != nil {
+		return fmt.Errorf("saving the arXiv's categories: %w", err)
+	}
+	changes.Log()
+	categoriesByCodeMap = newCategoriesByCodeMap
 
-	// save the categories in db
-	err := database.SaveArxivGroupsArchivesAndCategories(arxivGroupList)
+	err = database.SaveArxivTaxonomySync(source, version, changes)
 	if err != nil {
-		log.Fatalf("saving the arXiv's categories: %s", err)
+		log.Errorf("recording the taxonomy sync: %s", err)
+	}
+
+	// save the aliases and subsumed archives
+	newRelationList, err := database.SaveArxivCategoryRelations(getCategoryRelations(arxivCategoryList))
+	if err != nil {
+		return fmt.Errorf("saving the arXiv's category relations: %w", err)
+	}
+	for _, relation := range newRelationList {
+		log.Infof("new arXiv's category relation: %s → %s (%s)", relation.CategoryCode, relation.RelatedCategoryCode, relation.RelationType)
 	}
 
-	log.Info("arXiv categories updated")
+	log.Infof("arXiv categories updated (%s taxonomy)", source)
+	return nil
+}
+
+// isCategoryCode returns whether the code is in the taxonomy or shaped like arXiv's codes (i.e. may be registered as
+// provisional), unlike e.g. the author-supplied `J.2 (Physical sciences)`
+func isCategoryCode(categoryCode string) bool {
+	if _, exists := categoriesByCodeMap[categoryCode]; exists {
+		return true
+	}
+	return categoryCodeRegexp.MatchString(categoryCode)
+}
+
+// getOrRegisterCategory returns the category of the code, registering it as provisional if not in the taxonomy
+func getOrRegisterCategory(categoryCode string) (*database.ArxivCategory, error) {
+	if categoryCode == "" {
+		return nil, fmt.Errorf("empty category code")
+	}
+	if arxivCategory, exists := categoriesByCodeMap[categoryCode]; exists {
+		return arxivCategory, nil
+	}
+
+	arxivCategory, err := database.RegisterProvisionalArxivCategory(categoryCode)
+	if err != nil {
+		return nil, err
+	}
+	if categoriesByCodeMap == nil {
+		categoriesByCodeMap = make(map[string]*database.ArxivCategory)
+	}
+	categoriesByCodeMap[categoryCode] = arxivCategory
+	return arxivCategory, nil
 }
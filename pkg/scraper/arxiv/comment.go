@@ -0,0 +1,191 @@
+package arxiv
+
+import (
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/journalref"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	countPattern = `(\d+|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve)`
+	// e.g. `12 pages`, `12+5 pages` (main text + appendix), `12pp`
+	pageCountPattern = `(?i)\b(\d+)(?:\s*\+\s*(\d+))?\s*(?:pages?|pp)\b`
+	// e.g. `5 figures`, `one figure`, `3 color figs`
+	figureCountPattern = `(?i)\b` + countPattern + `\s+(?:(?:colou?r|eps|png)\s+)?(?:figures?|figs?)\b`
+	tableCountPattern  = `(?i)\b` + countPattern + `\s+tables?\b`
+	// URLs with a scheme, or the common hosts of code and datasets written without one (e.g. `code at github.com/...`)
+	commentUrlPattern = `(?i)\b(?:https?://|www\.|(?:github\.com|gitlab\.com|bitbucket\.org|zenodo\.org|huggingface\.co)/)[^\s,;<>"]+`
+	// e.g. `accepted at NeurIPS 2021`, `to appear in Phys. Rev. Lett.`, `accepted for publication in ...`
+	venueMentionPattern = `(?i)\b(accepted|to\s+appear|published|presented)` +
+		`(?:\s+(?:for\s+(?:publication|presentation)|as\s+an?\s+(?:oral|poster|spotlight|full|short|long)(?:\s+(?:paper|presentation|talk))?))?` +
+		`\s+(?:at|in|to|by|on|for)\s+([^;,]+)`
+	// e.g. `2021`, the years may be glued to the venue (e.g. `NeurIPS2021`, `ICLR'22`)
+	mentionYearPattern      = `\b((?:19|20)\d{2})\b`
+	gluedMentionYearPattern = `^([A-Za-z]+)['’]?((?:19|20)\d{2}|\d{2})$`
+	// the words of a mention looked up as a venue name (from the longest prefix)
+	maxVenueNameWordCount = 12
+)
+
+var pageCountRegexp = regexp.MustCompile(pageCountPattern)
+var figureCountRegexp = regexp.MustCompile(figureCountPattern)
+var tableCountRegexp = regexp.MustCompile(tableCountPattern)
+var commentUrlRegexp = regexp.MustCompile(commentUrlPattern)
+var venueMentionRegexp = regexp.MustCompile(venueMentionPattern)
+var mentionYearRegexp = regexp.MustCompile(mentionYearPattern)
+var gluedMentionYearRegexp = regexp.MustCompile(gluedMentionYearPattern)
+
+var countMapByWord = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+var mentionStatusMapByWord = map[string]string{
+	"accepted":  database.AcceptedMentionStatus,
+	"toappear":  database.ToAppearMentionStatus,
+	"published": database.PublishedMentionStatus,
+	"presented": database.PresentedMentionStatus,
+}
+
+// commentAnalysis is the structured content of an eprint's comment
+// (e.g. `12 pages, 5 figures, accepted at NeurIPS 2021, code at github.com/...`)
+type commentAnalysis struct {
+	pageCount     *int
+	figureCount   *int
+	tableCount    *int
+	urlList       []string
+	venueMentions []*database.ArxivVenueMention
+}
+
+// analyseComment extracts the page, figure and table counts, the URLs and the venue mentions of a comment (best effort)
+func analyseComment(comment string) *commentAnalysis {
+	analysis := &commentAnalysis{}
+	text := strings.Join(strings.Fields(comment), " ")
+	if text == "" {
+		return analysis
+	}
+
+	// URLs first, so that their content isn't mistaken for counts or venues
+	for _, url := range commentUrlRegexp.FindAllString(text, -1) {
+		url = strings.TrimRight(url, ".)]}")
+		if !strings.HasPrefix(strings.ToLower(url), "http") {
+			url = "https://" + url
+		}
+		analysis.urlList = append(analysis.urlList, url)
+	}
+	text = commentUrlRegexp.ReplaceAllString(text, " ")
+
+	if result := pageCountRegexp.FindStringSubmatch(text); result != nil {
+		pageCount, _ := strconv.Atoi(result[1])
+		if appendixPageCount, err := strconv.Atoi(result[2]); err == nil {
+			pageCount += appendixPageCount
+		}
+		analysis.pageCount = &pageCount
+	}
+	analysis.figureCount = parseCount(figureCountRegexp, text)
+	analysis.tableCount = parseCount(tableCountRegexp, text)
+
+	for _, result := range venueMentionRegexp.FindAllStringSubmatch(text, -1) {
+		analysis.venueMentions = append(analysis.venueMentions, parseVenueMention(result[1], result[2]))
+	}
+
+	return analysis
+}
+
+// applyTo sets the analysed fields of the eprint
+func (c *commentAnalysis) applyTo(arxivEprint *database.ArxivEprint) {
+	arxivEprint.PageCount = c.pageCount
+	arxivEprint.FigureCount = c.figureCount
+	arxivEprint.TableCount = c.tableCount
+	arxivEprint.CommentUrls = c.urlList
+	arxivEprint.VenueMentions = c.venueMentions
+}
+
+func parseCount(countRegexp *regexp.Regexp, text string) *int {
+	result := countRegexp.FindStringSubmatch(text)
+	if result == nil {
+		return nil
+	}
+	count, err := strconv.Atoi(result[1])
+	if err != nil {
+		count = countMapByWord[strings.ToLower(result[1])]
+	}
+	return &count
+}
+
+// parseVenueMention returns the mention with its year and venue (if a known one), e.g. `NeurIPS 2021 (spotlight)`
+func parseVenueMention(statusWord string, rawMention string) *database.ArxivVenueMention {
+	mention := &database.ArxivVenueMention{
+		Status:  mentionStatusMapByWord[strings.ToLower(strings.Join(strings.Fields(statusWord), ""))],
+		Mention: strings.TrimRight(strings.TrimSpace(rawMention), "."),
+	}
+
+	// the venue name is before the year (e.g. `NeurIPS 2021 workshop`), unless it starts with it (e.g. `2021 IEEE ...`)
+	venueName := mention.Mention
+	if result := mentionYearRegexp.FindStringSubmatchIndex(venueName); result != nil {
+		year, _ := strconv.Atoi(venueName[result[2]:result[3]])
+		mention.Year = &year
+		if before := strings.TrimSpace(venueName[:result[0]]); before != "" {
+			venueName = before
+		} else {
+			venueName = strings.TrimSpace(venueName[result[1]:])
+		}
+	}
+
+	mention.Venue = findMentionedVenue(venueName, mention)
+	return mention
+}
+
+// findMentionedVenue looks for the longest known venue name starting the mention, then for a known acronym in it
+// (e.g. `the 35th Conference on Neural Information Processing Systems (NeurIPS)`)
+func findMentionedVenue(venueName string, mention *database.ArxivVenueMention) *database.Venue {
+	wordList := strings.Fields(strings.TrimPrefix(strings.TrimPrefix(venueName, "the "), "The "))
+	if len(wordList) > maxVenueNameWordCount {
+		wordList = wordList[:maxVenueNameWordCount]
+	}
+	for wordCount := len(wordList); wordCount > 0; wordCount-- {
+		if venue := journalref.FindKnownVenue(strings.Join(wordList[:wordCount], " ")); venue != nil {
+			return venue
+		}
+	}
+
+	for _, word := range strings.FieldsFunc(venueName, isMentionWordSeparator) {
+		// the year may be glued to the acronym (e.g. `NeurIPS2021`, `ICLR'22`)
+		if result := gluedMentionYearRegexp.FindStringSubmatch(word); result != nil {
+			word = result[1]
+			if mention.Year == nil {
+				year, _ := strconv.Atoi(result[2])
+				if year < 100 {
+					year += 2000
+				}
+				mention.Year = &year
+			}
+		}
+		if !isAcronym(word) {
+			continue
+		}
+		if venue := journalref.FindKnownVenue(word); venue != nil {
+			return venue
+		}
+	}
+
+	return nil
+}
+
+func isMentionWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("()[]/-:", r)
+}
+
+// isAcronym tells whether the word has several upper case letters (e.g. `ICML`, `NeurIPS`), so that common words
+// matching a venue name aren't taken for it
+func isAcronym(word string) bool {
+	var upperCount int
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upperCount++
+		}
+	}
+	return upperCount >= 2
+}
@@ -0,0 +1,240 @@
+package arxiv
+
+import (
+	"fmt"
+	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/arxivid"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper/collector"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+const (
+	arxivIdListUrlPattern = arxivApiQueryUrl + "?id_list=%s&max_results=%d"
+	// stay well below the URL length limits of the servers and proxies
+	maxIdListUrlLength = 2000
+	fetchJobBatchSize  = 1000
+)
+
+// the request context's key of the id_list query's state (see idListFetch)
+const idListFetchContextKey = "idListFetch"
+
+// idListFetch is the state of an id_list query, passed to the parsers through the request context: the ids returned,
+// the error entries and the request error (if any)
+type idListFetch struct {
+	fetchedIdSet map[string]struct{}
+	errorList    []string
+	requestError error
+}
+
+// FetchReport is the outcome of a fetch: the requested ids found, not found, invalid or answered with an error entry
+type FetchReport struct {
+	RequestedCount int
+	FetchedIdList  []string
+	NotFoundIdList []string
+	InvalidIdList  []string
+	ErrorByArxivId map[string]string
+}
+
+// Log logs the summary of the fetch, and the ids which couldn't be fetched
+func (r *FetchReport) Log() {
+	log.Infof("fetched %d/%d arXiv ids (%d not found, %d invalid, %d errors)",
+		len(r.FetchedIdList), r.RequestedCount, len(r.NotFoundIdList), len(r.InvalidIdList), len(r.ErrorByArxivId))
+	for _, arxivId := range r.NotFoundIdList {
+		log.Warnf("arXiv id not found: %s", arxivId)
+	}
+	for _, arxivId := range r.InvalidIdList {
+		log.Warnf("invalid arXiv id: %s", arxivId)
+	}
+	for arxivId, message := range r.ErrorByArxivId {
+		log.Warnf("arXiv id %s answered with an error: %s", arxivId, message)
+	}
+}
+
+// FetchIds fetches the given arXiv ids (any form, see arxivid.Parse) with batched id_list queries,
+// the entries are saved like the scraped ones
+func FetchIds(website *database.Website, rawIdList []string) (*FetchReport, error) {
+	report := &FetchReport{
+		RequestedCount: len(rawIdList),
+		ErrorByArxivId: make(map[string]string),
+	}
+
+	// parse the ids (the latest version is fetched, whatever the requested one)
+	var idList []string
+	idSet := make(map[string]struct{})
+	for _, rawId := range rawIdList {
+		arxivId, err := arxivid.Parse(rawId)
+		if err != nil {
+			report.InvalidIdList = append(report.InvalidIdList, rawId)
+			continue
+		}
+		if _, exists := idSet[arxivId.Base]; exists {
+			continue
+		}
+		idList = append(idList, arxivId.Base)
+		idSet[arxivId.Base] = struct{}{}
+	}
+	if len(idList) == 0 {
+		return report, nil
+	}
+
+	// the entries are parsed with the known categories
+	if categoriesByCodeMap == nil {
+		err := UpdateAndLoadCategories(website)
+		if err != nil {
+			return nil, fmt.Errorf("loading the arXiv's categories: %w", err)
+		}
+	}
+
+	wc := collector.GetWebsiteCollector(website, colly.AllowURLRevisit())
+	SetupCollector(wc)
+	wc.Collector.OnError(func(r *colly.Response, err error) {
+		if fetch := getIdListFetch(r.Request); fetch != nil {
+			fetch.requestError = err
+		}
+	})
+
+	for _, batch := range getIdListBatches(idList, config.Arxiv.IdListBatchSize) {
+		fetchIdBatch(wc, batch, report)
+	}
+	wc.Stats.Log(website.Name + " id_list")
+
+	return report, nil
+}
+
+// fetchIdBatch fetches the batch of ids with an id_list query, and adds the outcome of each id to the report
+// An error entry doesn't tell which ids it's about (e.g. a single malformed id fails the whole query), so the batch is
+// bisected until the ids answered with an error are isolated
+func fetchIdBatch(wc *collector.WebsiteCollector, batch []string, report *FetchReport) {
+	fetch := &idListFetch{
+		fetchedIdSet: make(map[string]struct{}),
+	}
+	ctx := colly.NewContext()
+	ctx.Put(idListFetchContextKey, fetch)
+	wc.AddUrlWithContext(getIdListUrl(batch), ctx)
+
+	if len(fetch.errorList) > 0 && len(batch) > 1 {
+		log.Debugf("the id_list query of %d ids answered with an error, splitting it", len(batch))
+		middle := len(batch) / 2
+		fetchIdBatch(wc, batch[:middle], report)
+		fetchIdBatch(wc, batch[middle:], report)
+		return
+	}
+
+	for _, arxivId := range batch {
+		if _, exists := fetch.fetchedIdSet[arxivId]; exists {
+			report.FetchedIdList = append(report.FetchedIdList, arxivId)
+			continue
+		}
+		switch {
+		case fetch.requestError != nil:
+			report.ErrorByArxivId[arxivId] = fetch.requestError.Error()
+		case len(fetch.errorList) > 0:
+			report.ErrorByArxivId[arxivId] = strings.Join(fetch.errorList, "; ")
+		default:
+			report.NotFoundIdList = append(report.NotFoundIdList, arxivId)
+		}
+	}
+}
+
+// FetchQueuedIds fetches the pending arXiv ids of the fetch jobs table, and records the outcome of each job
+func FetchQueuedIds(website *database.Website) error {
+	for {
+		jobList, err := database.GetPendingArxivFetchJobs(fetchJobBatchSize)
+		if err != nil {
+			return fmt.Errorf("fetching the pending arXiv's fetch jobs: %w", err)
+		}
+		if len(jobList) == 0 {
+			return nil
+		}
+
+		var idList []string
+		for _, job := range jobList {
+			idList = append(idList, job.ArxivId)
+		}
+		report, err := FetchIds(website, idList)
+		if err != nil {
+			return err
+		}
+		report.Log()
+
+		err = updateFetchJobs(jobList, report)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func updateFetchJobs(jobList []*database.ArxivFetchJob, report *FetchReport) error {
+	fetchedSet := make(map[string]struct{})
+	for _, arxivId := range report.FetchedIdList {
+		fetchedSet[arxivId] = struct{}{}
+	}
+
+	for _, job := range jobList {
+		status := database.NotFoundFetchJobStatus
+		var message *string
+
+		arxivId, err := arxivid.Parse(job.ArxivId)
+		if err != nil {
+			status = database.ErrorFetchJobStatus
+			errorMessage := err.Error()
+			message = &errorMessage
+		} else if _, exists := fetchedSet[arxivId.Base]; exists {
+			status = database.FetchedFetchJobStatus
+		} else if errorMessage, exists := report.ErrorByArxivId[arxivId.Base]; exists {
+			status = database.ErrorFetchJobStatus
+			message = &errorMessage
+		}
+
+		err = job.UpdateStatus(status, message)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getIdListBatches splits the ids into batches of at most batchSize ids and maxIdListUrlLength characters of URL
+func getIdListBatches(idList []string, batchSize int) [][]string {
+	var batchList [][]string
+	var batch []string
+	for _, arxivId := range idList {
+		if len(batch) > 0 && (len(batch) >= batchSize || len(getIdListUrl(append(batch, arxivId))) > maxIdListUrlLength) {
+			batchList = append(batchList, batch)
+			batch = nil
+		}
+		batch = append(batch, arxivId)
+	}
+	if len(batch) > 0 {
+		batchList = append(batchList, batch)
+	}
+	return batchList
+}
+
+func getIdListUrl(idList []string) string {
+	return fmt.Sprintf(arxivIdListUrlPattern, strings.Join(idList, ","), len(idList))
+}
+
+// getIdListFetch returns the state of the id_list query of the request, nil if it isn't one (e.g. scraping)
+func getIdListFetch(r *colly.Request) *idListFetch {
+	fetch, _ := r.Ctx.GetAny(idListFetchContextKey).(*idListFetch)
+	return fetch
+}
+
+// recordFetchedId marks the id as returned, when fetching ids
+func recordFetchedId(r *colly.Request, arxivId *arxivid.ID) {
+	if fetch := getIdListFetch(r); fetch != nil {
+		fetch.fetchedIdSet[arxivId.Base] = struct{}{}
+	}
+}
+
+// recordFetchError keeps the error entry's message, when fetching ids
+func recordFetchError(r *colly.Request, message string) {
+	if fetch := getIdListFetch(r); fetch != nil {
+		fetch.errorList = append(fetch.errorList, message)
+	}
+}
@@ -0,0 +1,50 @@
+package arxiv
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// the notice starts the comment, e.g. `This paper has been withdrawn by the author(s) due to a crucial error in
+	// equation 2`, `Withdrawn by arXiv administrators`, `Paper withdrawn.` (not e.g. `previous version was withdrawn`)
+	withdrawalPattern = `(?i)^\s*(?:(?:this|the)\s+)?(?:(?:paper|article|submission|manuscript|preprint|work)\s+(?:has\s+been\s+|is\s+|was\s+)?)?withdrawn` +
+		`(?:\s+by\s+(?:the\s+)?(?:authors?|arxiv)\b|\s*(?:[.,;:!(]|$)|\s+(?:due\s+to|because|as|since|for|pending)\b)`
+	// the reason follows the notice, e.g. `due to ...`, `because ...`, `: ...`
+	withdrawalReasonPattern = `(?i)withdrawn[^.;:]*?(?:\s+(?:due\s+to|because\s+of|because|as|since)\s+|:\s*)(.+)`
+	reportNumberSeparators  = ";,"
+)
+
+var withdrawalRegexp = regexp.MustCompile(withdrawalPattern)
+var withdrawalReasonRegexp = regexp.MustCompile(withdrawalReasonPattern)
+
+// parseWithdrawal tells whether a comment is a withdrawal notice, and returns its reason if any
+func parseWithdrawal(text string) (bool, *string) {
+	if !withdrawalRegexp.MatchString(text) {
+		return false, nil
+	}
+
+	result := withdrawalReasonRegexp.FindStringSubmatch(text)
+	if len(result) < 2 {
+		return true, nil
+	}
+	reason := strings.TrimRight(strings.TrimSpace(result[1]), ".")
+	if reason == "" {
+		return true, nil
+	}
+	return true, &reason
+}
+
+// parseReportNumbers splits the report numbers (e.g. `CERN-TH-2019-001, DESY 19-001`)
+func parseReportNumbers(text string) []string {
+	var reportNumberList []string
+	for _, reportNumber := range strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune(reportNumberSeparators, r)
+	}) {
+		reportNumber = strings.TrimSpace(reportNumber)
+		if reportNumber != "" {
+			reportNumberList = append(reportNumberList, reportNumber)
+		}
+	}
+	return reportNumberList
+}
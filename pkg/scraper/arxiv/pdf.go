@@ -0,0 +1,153 @@
+package arxiv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/blob"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper/collector"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+const (
+	pdfContentType       = "application/pdf"
+	pdfEprintContextKey  = "arxivEprint"
+	pdfStorageKeyPattern = "arxiv/pdf/%sv%d.pdf"
+)
+
+// DownloadPdfs downloads the PDFs of the latest eprints' versions not downloaded yet, following the arXiv's pacing
+// The PDFs already in the blob store (e.g. the document wasn't saved) are only recorded, not downloaded again
+func DownloadPdfs(website *database.Website) error {
+	store, err := blob.NewStore()
+	if err != nil {
+		return fmt.Errorf("preparing the blob store: %w", err)
+	}
+
+	arxivEprintList, err := database.GetArxivEprintsWithoutDocument(config.Arxiv.PdfDownloadLimit)
+	if err != nil {
+		return fmt.Errorf("fetching the arXiv's eprints to download: %w", err)
+	}
+	log.Infof("%d arXiv's eprints PDFs to download", len(arxivEprintList))
+
+	wc := collector.GetDownloadCollector(website)
+	wc.Collector.OnResponse(pdfParser(store))
+
+	for _, arxivEprint := range arxivEprintList {
+		if isStored := saveStoredPdf(store, arxivEprint); isStored {
+			continue
+		}
+
+		ctx := colly.NewContext()
+		ctx.Put(pdfEprintContextKey, arxivEprint)
+		pdfUrl, err := getPdfUrl(arxivEprint)
+		if err != nil {
+			log.Errorf("no PDF URL for the arXiv's eprint %s: %s", arxivEprint.ArxivId, err)
+			continue
+		}
+		err = wc.Collector.Request("GET", pdfUrl, nil, ctx, nil)
+		if err != nil {
+			log.Errorf("error visiting %s: %s", pdfUrl, err)
+		}
+	}
+
+	wc.Stats.Log(website.Name + " PDFs")
+	return nil
+}
+
+func pdfParser(store blob.Store) func(r *colly.Response) {
+	return func(r *colly.Response) {
+		arxivEprint, ok := r.Ctx.GetAny(pdfEprintContextKey).(*database.ArxivEprint)
+		if !ok {
+			log.Errorf("no arXiv's eprint attached to the PDF request %s", r.Request.URL)
+			return
+		}
+
+		contentType := r.Headers.Get("Content-Type")
+		if !strings.HasPrefix(contentType, pdfContentType) {
+			log.Errorf("unexpected content type `%s` for the PDF of %s", contentType, arxivEprint.ArxivId)
+			return
+		}
+
+		document := newPdfDocument(arxivEprint, r.Body)
+		err := store.Put(document.StorageKey, r.Body, pdfContentType)
+		if err != nil {
+			log.Errorf("storing the PDF of %s: %s", arxivEprint.ArxivId, err)
+			return
+		}
+
+		err = document.Save()
+		if err != nil {
+			log.Errorf("saving the document of %s: %s", arxivEprint.ArxivId, err)
+			return
+		}
+
+		log.Infof("successfully downloaded the PDF of %s (%d bytes)", arxivEprint.ArxivId, document.Size)
+	}
+}
+
+// saveStoredPdf saves the document of the eprint's PDF if it's already in the blob store, and returns whether it was
+func saveStoredPdf(store blob.Store, arxivEprint *database.ArxivEprint) bool {
+	storageKey := getPdfStorageKey(arxivEprint)
+	isStored, err := store.Exists(storageKey)
+	if err != nil {
+		log.Warnf("checking the stored PDF of %s: %s", arxivEprint.ArxivId, err)
+		return false
+	}
+	if !isStored {
+		return false
+	}
+
+	content, err := store.Get(storageKey)
+	if err != nil {
+		log.Warnf("reading the stored PDF of %s: %s", arxivEprint.ArxivId, err)
+		return false
+	}
+
+	document := newPdfDocument(arxivEprint, content)
+	err = document.Save()
+	if err != nil {
+		log.Errorf("saving the document of %s: %s", arxivEprint.ArxivId, err)
+		return true
+	}
+
+	log.Infof("the PDF of %s was already stored (%d bytes)", arxivEprint.ArxivId, document.Size)
+	return true
+}
+
+func newPdfDocument(arxivEprint *database.ArxivEprint, content []byte) *database.Document {
+	hash := sha256.Sum256(content)
+	return &database.Document{
+		ContentType:   pdfContentType,
+		Sha256:        hex.EncodeToString(hash[:]),
+		Size:          int64(len(content)),
+		StorageKey:    getPdfStorageKey(arxivEprint),
+		Version:       arxivEprint.LatestVersion,
+		DownloadedAt:  time.Now(),
+		ArxivEprintId: arxivEprint.Id,
+		PaperId:       arxivEprint.PaperId,
+	}
+}
+
+func getPdfUrl(arxivEprint *database.ArxivEprint) (string, error) {
+	if arxivEprint.PdfLink != nil && *arxivEprint.PdfLink != "" {
+		return *arxivEprint.PdfLink, nil
+	}
+	arxivId, err := arxivEprint.GetArxivId()
+	if err != nil {
+		return "", err
+	}
+	return arxivId.PdfUrl(), nil
+}
+
+func getPdfStorageKey(arxivEprint *database.ArxivEprint) string {
+	baseId := arxivEprint.ArxivId
+	if arxivId, err := arxivEprint.GetArxivId(); err == nil {
+		baseId = arxivId.Base
+	}
+	return fmt.Sprintf(pdfStorageKeyPattern, baseId, arxivEprint.LatestVersion)
+}
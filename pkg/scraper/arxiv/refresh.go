@@ -0,0 +1,107 @@
+package arxiv
+
+import (
+	"fmt"
+	"github.com/antchfx/xmlquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/config"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper/collector"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+const (
+	refreshSortBy    = "lastUpdatedDate"
+	refreshSortOrder = "descending"
+)
+
+// the oldest and newest update dates of the entries parsed since the start of the category's refresh
+var oldestUpdatedAtByCategoryCode = make(map[string]time.Time)
+var newestUpdatedAtByCategoryCode = make(map[string]time.Time)
+
+// RefreshCategoryList fetches the eprints updated since the previous refresh (most recently updated first),
+// category by category, so the eprints revised after being scraped get updated
+func RefreshCategoryList(wc *collector.WebsiteCollector) {
+	for _, category := range config.Arxiv.CategoryList {
+		err := refreshCategory(wc, category)
+		if err != nil {
+			log.Errorf("refreshing the arXiv's category %s: %s", category, err)
+		}
+	}
+}
+
+func refreshCategory(wc *collector.WebsiteCollector, categoryCode string) error {
+	category, present := categoriesByCodeMap[categoryCode]
+	if !present {
+		return fmt.Errorf("unknown arXiv category code: %s", categoryCode)
+	}
+
+	// the first refresh goes back to the configured lookback
+	watermark, err := database.GetArxivRefreshWatermark(categoryCode)
+	if err != nil {
+		return err
+	}
+	if watermark == nil {
+		lookbackWatermark := time.Now().Add(-config.Arxiv.RefreshLookback)
+		watermark = &lookbackWatermark
+	}
+	log.Infof("refreshing the arXiv's eprints of category %s updated since %s", categoryCode, watermark.Format(time.RFC3339))
+
+	ac := config.Arxiv
+	delete(oldestUpdatedAtByCategoryCode, categoryCode)
+	delete(newestUpdatedAtByCategoryCode, categoryCode)
+
+	start := 0
+	for {
+		// unset until the feed is parsed (i.e. the request failed if still unset)
+		delete(isLastResultEmptyByCategoryCode, categoryCode)
+
+		queryString := fmt.Sprintf(arxivQueryPattern, arxivBaseSearchUrl, category.OriginalArxivCategoryCode, start, ac.MaxResults, refreshSortBy, refreshSortOrder)
+		wc.AddUrl(queryString)
+		start += ac.MaxResults
+
+		isLastResultEmpty, isParsed := isLastResultEmptyByCategoryCode[categoryCode]
+		if !isParsed {
+			return fmt.Errorf("no feed parsed for %s, keeping the previous watermark", queryString)
+		}
+		if isLastResultEmpty {
+			break
+		}
+		if oldestUpdatedAt := oldestUpdatedAtByCategoryCode[categoryCode]; !oldestUpdatedAt.After(*watermark) {
+			break
+		}
+	}
+
+	// move the watermark to the most recent update seen
+	newestUpdatedAt, exists := newestUpdatedAtByCategoryCode[categoryCode]
+	if !exists || !newestUpdatedAt.After(*watermark) {
+		log.Infof("no arXiv's eprint of category %s updated since the previous refresh", categoryCode)
+		return nil
+	}
+	err = database.SaveArxivRefreshWatermark(categoryCode, newestUpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("refreshed the arXiv's eprints of category %s updated until %s", categoryCode, newestUpdatedAt.Format(time.RFC3339))
+	return nil
+}
+
+// trackUpdatedDates records the oldest and newest update dates of the feed's entries
+func trackUpdatedDates(categoryCode string, e *colly.XMLElement) {
+	for _, updatedNode := range xmlquery.Find(e.DOM.(*xmlquery.Node), "entry/updated") {
+		updatedAt, err := time.Parse(time.RFC3339, strings.TrimSpace(updatedNode.InnerText()))
+		if err != nil {
+			continue
+		}
+
+		if oldestUpdatedAt, exists := oldestUpdatedAtByCategoryCode[categoryCode]; !exists || updatedAt.Before(oldestUpdatedAt) {
+			oldestUpdatedAtByCategoryCode[categoryCode] = updatedAt
+		}
+		if newestUpdatedAt, exists := newestUpdatedAtByCategoryCode[categoryCode]; !exists || updatedAt.After(newestUpdatedAt) {
+			newestUpdatedAtByCategoryCode[categoryCode] = updatedAt
+		}
+	}
+}
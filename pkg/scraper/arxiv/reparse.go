@@ -0,0 +1,52 @@
+package arxiv
+
+import (
+	"fmt"
+	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper/archive"
+	"github.com/papetier/scraper/pkg/scraper/collector"
+	log "github.com/sirupsen/logrus"
+)
+
+// the request context's key marking the replayed requests (the saved eprints are then replaced, not only updated)
+const replayContextKey = "isReplay"
+
+// ReparseArchive replays the archived arXiv's responses through the current parsers, without network access
+func ReparseArchive(website *database.Website) error {
+	// load the categories from the archived taxonomy (not synced into the database), else the saved ones
+	err := loadArchivedCategories(collector.GetReplayCollector(website))
+	if err != nil {
+		log.Warnf("loading the archived categories: %s", err)
+		err = loadCategoriesFromDatabase()
+		if err != nil {
+			return fmt.Errorf("loading the saved categories: %w", err)
+		}
+	}
+
+	urlList, err := archive.NewArchive(website.Name).ListUrls()
+	if err != nil {
+		return fmt.Errorf("listing the archived URLs: %w", err)
+	}
+
+	wc := collector.GetReplayCollector(website)
+	wc.Collector.OnRequest(func(r *colly.Request) {
+		r.Ctx.Put(replayContextKey, "true")
+	})
+	SetupCollector(wc)
+
+	for _, url := range urlList {
+		if url == arxivCategoryTaxonomyUrl {
+			continue
+		}
+		wc.AddUrl(url)
+	}
+
+	log.Infof("replayed %d archived arXiv's responses", len(urlList))
+	return nil
+}
+
+// isReplayRequest returns whether the request is replayed from the archive (see ReparseArchive)
+func isReplayRequest(r *colly.Request) bool {
+	return r.Ctx.Get(replayContextKey) == "true"
+}
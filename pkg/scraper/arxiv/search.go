@@ -10,7 +10,7 @@ import (
 )
 
 const (
-	arxivBaseSearchUrl         = "http://export.arxiv.org/api/query?search_query="
+	arxivBaseSearchUrl         = arxivApiQueryUrl + "?search_query="
 	arxivQueryPattern          = "%scat:%s&start=%d&max_results=%d&sortBy=%s&sortOrder=%s"
 	searchQueryCategoryPattern = `cat:(.+)`
 	searchQueryTitlePattern    = `(.*): search_query=(.*)&id_list=(.*)&start=(\d+)&max_results=(\d+)`
@@ -19,8 +19,8 @@ const (
 var searchQueryTitleRegex = regexp.MustCompile(searchQueryTitlePattern)
 var searchQueryCategoryRegex = regexp.MustCompile(searchQueryCategoryPattern)
 
-var duplicatedPaperCounterByCategoryCode map[string]int
-var isLastResultEmptyByCategoryCode map[string]bool
+var duplicatedPaperCounterByCategoryCode = make(map[string]int)
+var isLastResultEmptyByCategoryCode = make(map[string]bool)
 
 func SearchCategoryList(wc *collector.WebsiteCollector) {
 	// prepare tracker maps
@@ -55,6 +55,12 @@ func searchCategory(wc *collector.WebsiteCollector, categoryCode string) {
 	}
 }
 
+// isIdListFeedTitle tells whether the feed answers an id_list query (without search query)
+func isIdListFeedTitle(title string) bool {
+	queryResult := searchQueryTitleRegex.FindStringSubmatch(title)
+	return len(queryResult) > 3 && queryResult[2] == "" && queryResult[3] != ""
+}
+
 func getCategoryCodeFromSearchFeedTitle(title string) *string {
 	// extract canonical query
 	queryResult := searchQueryTitleRegex.FindStringSubmatch(title)
@@ -0,0 +1,141 @@
+package arxiv
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+)
+
+//go:embed taxonomy.tsv
+var taxonomySnapshotFile string
+
+const (
+	aliasPattern           = `([a-z\-]+(?:\.[A-Za-z\-]+)?) is an alias for ([a-z\-]+(?:\.[A-Za-z\-]+)?)`
+	snapshotVersionComment = "# version:"
+)
+
+var aliasRegexp = regexp.MustCompile(aliasPattern)
+
+// the legacy archives merged into categories (no longer listed in the taxonomy, but used by the old eprints)
+var subsumedArchiveMap = map[string]string{
+	"acc-phys": "physics.acc-ph",
+	"adap-org": "nlin.AO",
+	"alg-geom": "math.AG",
+	"ao-sci":   "physics.ao-ph",
+	"atom-ph":  "physics.atom-ph",
+	"bayes-an": "physics.data-an",
+	"chao-dyn": "nlin.CD",
+	"chem-ph":  "physics.chem-ph",
+	"cmp-lg":   "cs.CL",
+	"comp-gas": "nlin.CG",
+	"dg-ga":    "math.DG",
+	"funct-an": "math.FA",
+	"mtrl-th":  "cond-mat.mtrl-sci",
+	"patt-sol": "nlin.PS",
+	"plasm-ph": "physics.plasm-ph",
+	"q-alg":    "math.QA",
+	"solv-int": "nlin.SI",
+	"supr-con": "cond-mat.supr-con",
+}
+
+// getCategoryRelations returns the aliases stated in the categories' names and descriptions
+// (e.g. `math.IT is an alias for cs.IT.`), and the subsumed legacy archives
+func getCategoryRelations(categoryList []*database.ArxivCategory) []*database.ArxivCategoryRelation {
+	var relationList []*database.ArxivCategoryRelation
+	relationSet := make(map[string]struct{})
+	addRelation := func(categoryCode, relatedCategoryCode, relationType string) {
+		key := categoryCode + " " + relatedCategoryCode + " " + relationType
+		if _, exists := relationSet[key]; exists || categoryCode == relatedCategoryCode {
+			return
+		}
+		relationList = append(relationList, &database.ArxivCategoryRelation{
+			CategoryCode:        categoryCode,
+			RelatedCategoryCode: relatedCategoryCode,
+			RelationType:        relationType,
+		})
+		relationSet[key] = struct{}{}
+	}
+
+	for _, category := range categoryList {
+		for _, text := range []string{category.OriginalArxivCategoryName, category.OriginalArxivCategoryDescription} {
+			for _, result := range aliasRegexp.FindAllStringSubmatch(text, -1) {
+				addRelation(result[1], result[2], database.AliasCategoryRelation)
+			}
+		}
+	}
+
+	for archiveCode, categoryCode := range subsumedArchiveMap {
+		addRelation(archiveCode, categoryCode, database.SubsumedCategoryRelation)
+	}
+
+	return relationList
+}
+
+// loadSnapshotCategories syncs the categories of the bundled taxonomy snapshot into the database, then loads them
+func loadSnapshotCategories() error {
+	groupList, version, err := parseTaxonomySnapshot(taxonomySnapshotFile)
+	if err != nil {
+		return fmt.Errorf("parsing the taxonomy snapshot: %w", err)
+	}
+	log.Warnf("loading the categories from the bundled taxonomy snapshot (version %s)", version)
+
+	return saveCategories(groupList, database.SnapshotTaxonomySource, &version)
+}
+
+// parseTaxonomySnapshot returns the groups (with archives and categories) of the snapshot, and its version
+func parseTaxonomySnapshot(content string) ([]*database.ArxivGroup, string, error) {
+	var groupList []*database.ArxivGroup
+	groupByName := make(map[string]*database.ArxivGroup)
+	archiveByCode := make(map[string]*database.ArxivArchive)
+	version := ""
+
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, snapshotVersionComment) {
+			version = strings.TrimSpace(strings.TrimPrefix(line, snapshotVersionComment))
+			continue
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fieldList := strings.Split(line, "\t")
+		if len(fieldList) != 5 {
+			return nil, "", fmt.Errorf("line %d: expected 5 fields, got %d", i+1, len(fieldList))
+		}
+		groupName, archiveCode, archiveName, categoryCode, categoryName := fieldList[0], fieldList[1], fieldList[2], fieldList[3], fieldList[4]
+
+		group, exists := groupByName[groupName]
+		if !exists {
+			group = &database.ArxivGroup{
+				OriginalArxivGroupName: groupName,
+			}
+			groupByName[groupName] = group
+			groupList = append(groupList, group)
+		}
+
+		archive, exists := archiveByCode[archiveCode]
+		if !exists {
+			archive = &database.ArxivArchive{
+				OriginalArxivArchiveCode: archiveCode,
+				OriginalArxivArchiveName: archiveName,
+				ArxivGroup:               group,
+			}
+			archiveByCode[archiveCode] = archive
+			group.ArxivArchives = append(group.ArxivArchives, archive)
+		}
+
+		archive.ArxivCategories = append(archive.ArxivCategories, &database.ArxivCategory{
+			OriginalArxivCategoryCode: categoryCode,
+			OriginalArxivCategoryName: categoryName,
+			ArxivArchive:              archive,
+		})
+	}
+
+	if version == "" {
+		return nil, "", fmt.Errorf("no version found")
+	}
+	return groupList, version, nil
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/papetier/scraper/pkg/database"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const responsesTable = "http_cache_responses"
+
+// Recorder receives the cache hits and misses (i.e. the run statistics)
+type Recorder interface {
+	IncrementCacheHits()
+	IncrementCacheMisses()
+	IncrementCacheRevalidated()
+}
+
+// Transport is an http.RoundTripper caching the GET responses in PostgreSQL
+// A cached response is served as is for the TTL, then revalidated with a conditional request (ETag/Last-Modified)
+// The responses of the URLs with a revalidated prefix (e.g. API queries, whose results change) are always revalidated
+type Transport struct {
+	pool                     *pgxpool.Pool
+	Base                     http.RoundTripper
+	Namespace                string
+	Recorder                 Recorder
+	ResponsesTable           string
+	RevalidatedUrlPrefixList []string
+	TTL                      time.Duration
+}
+
+type entry struct {
+	StatusCode   int
+	Headers      http.Header
+	Body         []byte
+	ETag         string
+	LastModified string
+	FetchedAt    time.Time
+}
+
+var once sync.Once
+
+func NewTransport(namespace string, base http.RoundTripper, ttl time.Duration, recorder Recorder) *Transport {
+	return &Transport{
+		pool:           database.GetPool(),
+		Base:           base,
+		Namespace:      namespace,
+		Recorder:       recorder,
+		ResponsesTable: responsesTable,
+		TTL:            ttl,
+	}
+}
+
+func prepareDB(t *Transport) {
+	once.Do(func() {
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			namespace text NOT NULL,
+			url text NOT NULL,
+			status_code integer NOT NULL,
+			headers jsonb NOT NULL,
+			body bytea NOT NULL,
+			etag text,
+			last_modified text,
+			fetched_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (namespace, url)
+		);`, t.ResponsesTable)
+		_, err := t.pool.Exec(context.Background(), query)
+		if err != nil {
+			log.Fatal(err)
+		}
+	})
+}
+
+// Init initializes the cache's table
+func (t *Transport) Init() {
+	prepareDB(t)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Base.RoundTrip(req)
+	}
+
+	url := req.URL.String()
+	cached, err := t.get(url)
+	if err != nil {
+		log.Errorf("reading the cached response of %s: %s", url, err)
+	}
+
+	// fresh cached response
+	if cached != nil && time.Since(cached.FetchedAt) < t.TTL && !t.isRevalidated(url) {
+		log.Debugf("serving cached response of %s", url)
+		t.Recorder.IncrementCacheHits()
+		return cached.toResponse(req), nil
+	}
+
+	// conditional request
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	res, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// not modified: refresh and serve the cached response
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		log.Debugf("cached response of %s is still valid", url)
+		t.Recorder.IncrementCacheRevalidated()
+		err = t.touch(url)
+		if err != nil {
+			log.Errorf("refreshing the cached response of %s: %s", url, err)
+		}
+		return cached.toResponse(req), nil
+	}
+
+	t.Recorder.IncrementCacheMisses()
+	if res.StatusCode != http.StatusOK {
+		return res, nil
+	}
+
+	// read the body to cache it, and give it back to the response
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading the response body of %s: %w", url, err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = t.save(url, res, body)
+	if err != nil {
+		log.Errorf("caching the response of %s: %s", url, err)
+	}
+
+	return res, nil
+}
+
+// isRevalidated returns whether the cached response of the URL must be revalidated, even if fresh
+func (t *Transport) isRevalidated(url string) bool {
+	for _, prefix := range t.RevalidatedUrlPrefixList {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transport) get(url string) (*entry, error) {
+	query := fmt.Sprintf(`SELECT status_code, headers, body, coalesce(etag, ''), coalesce(last_modified, ''), fetched_at FROM %s WHERE namespace = $1 AND url = $2`, t.ResponsesTable)
+
+	rows, err := t.pool.Query(context.Background(), query, t.Namespace, url)
+	defer rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fetching the cached response: %w", err)
+	}
+
+	var cached *entry
+	for rows.Next() {
+		cached = &entry{}
+		var headersJson []byte
+		err = rows.Scan(&cached.StatusCode, &headersJson, &cached.Body, &cached.ETag, &cached.LastModified, &cached.FetchedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning the cached response: %w", err)
+		}
+		err = json.Unmarshal(headersJson, &cached.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("decoding the cached headers: %w", err)
+		}
+	}
+
+	return cached, nil
+}
+
+func (t *Transport) save(url string, res *http.Response, body []byte) error {
+	etag := res.Header.Get("ETag")
+	lastModified := res.Header.Get("Last-Modified")
+
+	// nothing to reuse
+	if t.TTL <= 0 && etag == "" && lastModified == "" {
+		return nil
+	}
+
+	headersJson, err := json.Marshal(res.Header)
+	if err != nil {
+		return fmt.Errorf("encoding the headers: %w", err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (namespace, url, status_code, headers, body, etag, last_modified, fetched_at) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), now())
+		ON CONFLICT (namespace, url) DO UPDATE SET status_code = EXCLUDED.status_code, headers = EXCLUDED.headers, body = EXCLUDED.body, etag = EXCLUDED.etag, last_modified = EXCLUDED.last_modified, fetched_at = EXCLUDED.fetched_at`, t.ResponsesTable)
+	_, err = t.pool.Exec(context.Background(), query, t.Namespace, url, res.StatusCode, headersJson, body, etag, lastModified)
+	if err != nil {
+		return fmt.Errorf("upserting the cached response: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Transport) touch(url string) error {
+	query := fmt.Sprintf(`UPDATE %s SET fetched_at = now() WHERE namespace = $1 AND url = $2`, t.ResponsesTable)
+	_, err := t.pool.Exec(context.Background(), query, t.Namespace, url)
+	return err
+}
+
+func (e *entry) toResponse(req *http.Request) *http.Response {
+	headers := e.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	// the body and headers are cached as received from the network (i.e. possibly still encoded)
+	headers.Set("Content-Length", strconv.Itoa(len(e.Body)))
+
+	return &http.Response{
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
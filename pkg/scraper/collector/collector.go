@@ -5,6 +5,8 @@ import (
 	"github.com/gocolly/colly/v2"
 	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
+	"github.com/papetier/scraper/pkg/scraper/archive"
+	"github.com/papetier/scraper/pkg/scraper/cache"
 	"github.com/papetier/scraper/pkg/scraper/storage"
 	log "github.com/sirupsen/logrus"
 	"net"
@@ -15,6 +17,9 @@ import (
 type WebsiteCollector struct {
 	Website   *database.Website
 	Collector *colly.Collector
+	Stats     *Stats
+
+	cache *cache.Transport
 }
 
 func (wc *WebsiteCollector) AddUrl(url string) {
@@ -24,21 +29,102 @@ func (wc *WebsiteCollector) AddUrl(url string) {
 	}
 }
 
+// AddUrlWithContext visits the URL with the given request context (e.g. a state shared with the parsers)
+func (wc *WebsiteCollector) AddUrlWithContext(url string, ctx *colly.Context) {
+	err := wc.Collector.Request(http.MethodGet, url, nil, ctx, nil)
+	if err != nil {
+		log.Errorf("error visiting %s: %s", url, err)
+	}
+}
+
+// RevalidateUrlPrefix makes the cached responses of the URLs with this prefix revalidated on every request (i.e. never
+// served from the cache's TTL), e.g. for the API queries whose results change
+func (wc *WebsiteCollector) RevalidateUrlPrefix(prefix string) {
+	if wc.cache == nil {
+		return
+	}
+	wc.cache.RevalidatedUrlPrefixList = append(wc.cache.RevalidatedUrlPrefixList, prefix)
+}
+
 func GetWebsiteCollector(website *database.Website, options ...colly.CollectorOption) *WebsiteCollector {
-	// new colly collector
-	collectorOptions := options
-	collectorOptions = append(collectorOptions, colly.AllowedDomains(website.DomainList...))
-	c := colly.NewCollector(collectorOptions...)
+	stats := &Stats{}
+	c := newCollector(website, stats, options...)
 
 	// http settings
-	c.WithTransport(&http.Transport{
+	var transport http.RoundTripper = newTransport()
+	var cacheTransport *cache.Transport
+	if config.Cache.IsEnabled {
+		cacheTransport = cache.NewTransport(website.Name, transport, config.Cache.TTL, stats)
+		cacheTransport.Init()
+		transport = cacheTransport
+	}
+	c.WithTransport(transport)
+	c.SetRequestTimeout(config.Arxiv.RequestTimeout)
+	setLimits(c)
+
+	// storage set up
+	err := c.SetStorage(storage.NewStorage(website.Name))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// raw response archive
+	if config.Archive.IsEnabled() {
+		a := archive.NewArchive(website.Name)
+		a.Init()
+		c.OnResponse(a.OnResponse())
+	}
+
+	return &WebsiteCollector{
+		Website:   website,
+		Collector: c,
+		Stats:     stats,
+		cache:     cacheTransport,
+	}
+}
+
+// GetReplayCollector returns a collector answering every request from the website's raw response archive
+func GetReplayCollector(website *database.Website) *WebsiteCollector {
+	stats := &Stats{}
+	c := newCollector(website, stats, colly.AllowURLRevisit())
+
+	a := archive.NewArchive(website.Name)
+	a.Init()
+	c.WithTransport(archive.NewReplayTransport(a))
+
+	return &WebsiteCollector{
+		Website:   website,
+		Collector: c,
+		Stats:     stats,
+	}
+}
+
+// GetDownloadCollector returns a collector for binary downloads (e.g. PDFs): without body size limit, cache nor archive
+func GetDownloadCollector(website *database.Website) *WebsiteCollector {
+	stats := &Stats{}
+	c := newCollector(website, stats, colly.AllowURLRevisit(), colly.MaxBodySize(0))
+
+	c.WithTransport(newTransport())
+	c.SetRequestTimeout(config.Arxiv.RequestTimeout)
+	setLimits(c)
+
+	return &WebsiteCollector{
+		Website:   website,
+		Collector: c,
+		Stats:     stats,
+	}
+}
+
+func newTransport() *http.Transport {
+	return &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Arxiv.IsInsecureHttpAccepted},
 		DialContext: (&net.Dialer{
 			Timeout: config.Arxiv.RequestTimeout,
 		}).DialContext,
-	})
-	c.SetRequestTimeout(config.Arxiv.RequestTimeout)
+	}
+}
 
+func setLimits(c *colly.Collector) {
 	// slow down colly to avoid saturating arXiv
 	// following https://arxiv.org/help/api/tou#limitations
 	err := c.Limit(&colly.LimitRule{
@@ -49,26 +135,29 @@ func GetWebsiteCollector(website *database.Website, options ...colly.CollectorOp
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	// storage set up
-	err = c.SetStorage(storage.DbStorage)
-	if err != nil {
-		log.Fatal(err)
-	}
+func newCollector(website *database.Website, stats *Stats, options ...colly.CollectorOption) *colly.Collector {
+	// new colly collector
+	collectorOptions := options
+	collectorOptions = append(collectorOptions, colly.AllowedDomains(website.DomainList...))
+	c := colly.NewCollector(collectorOptions...)
 
 	// basic callbacks
 	c.OnRequest(func(r *colly.Request) {
+		stats.IncrementRequests()
 		log.Infof("fetching: %s", r.URL)
 	})
+	c.OnResponse(func(r *colly.Response) {
+		stats.IncrementResponses()
+	})
 	c.OnError(func(r *colly.Response, err error) {
+		stats.IncrementErrors()
 		log.WithField("collector", website.Name).Errorf("request URL: %v failed with HTTP status %v: %s", r.Request.URL.String(), r.StatusCode, err)
 	})
 	c.OnScraped(onScraped())
 
-	return &WebsiteCollector{
-		Website:   website,
-		Collector: c,
-	}
+	return c
 }
 
 func onScraped() func(r *colly.Response) {
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync/atomic"
+)
+
+// Stats counts the collector's activity during a run
+type Stats struct {
+	Requests         int64
+	Responses        int64
+	Errors           int64
+	CacheHits        int64
+	CacheMisses      int64
+	CacheRevalidated int64
+}
+
+func (s *Stats) IncrementRequests() {
+	atomic.AddInt64(&s.Requests, 1)
+}
+
+func (s *Stats) IncrementResponses() {
+	atomic.AddInt64(&s.Responses, 1)
+}
+
+func (s *Stats) IncrementErrors() {
+	atomic.AddInt64(&s.Errors, 1)
+}
+
+func (s *Stats) IncrementCacheHits() {
+	atomic.AddInt64(&s.CacheHits, 1)
+}
+
+func (s *Stats) IncrementCacheMisses() {
+	atomic.AddInt64(&s.CacheMisses, 1)
+}
+
+func (s *Stats) IncrementCacheRevalidated() {
+	atomic.AddInt64(&s.CacheRevalidated, 1)
+}
+
+// Log prints the run statistics
+func (s *Stats) Log(name string) {
+	log.WithField("collector", name).Infof(
+		"run statistics: %d requests, %d responses, %d errors, cache: %d hits, %d revalidated, %d misses",
+		atomic.LoadInt64(&s.Requests),
+		atomic.LoadInt64(&s.Responses),
+		atomic.LoadInt64(&s.Errors),
+		atomic.LoadInt64(&s.CacheHits),
+		atomic.LoadInt64(&s.CacheRevalidated),
+		atomic.LoadInt64(&s.CacheMisses),
+	)
+}
@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"github.com/gocolly/colly/v2"
+	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
 	"github.com/papetier/scraper/pkg/scraper/arxiv"
 	"github.com/papetier/scraper/pkg/scraper/collector"
@@ -10,10 +11,6 @@ import (
 	"sync"
 )
 
-func Setup() {
-	storage.SetupSDBStorage()
-}
-
 func ScrapeWebsites(websiteList []*database.Website) {
 	var wg sync.WaitGroup
 
@@ -41,7 +38,7 @@ func scrape(website *database.Website, wg *sync.WaitGroup) {
 		log.Info("categories successfully loaded --------- now starting scraper!")
 
 		// set up arXiv collector
-		arxiv.SetupCollector(wc.Collector)
+		arxiv.SetupCollector(wc)
 
 		// visit init URLs
 		arxiv.VisitInitUrlList(wc)
@@ -49,4 +46,107 @@ func scrape(website *database.Website, wg *sync.WaitGroup) {
 		// launch search on categories
 		arxiv.SearchCategoryList(wc)
 	}
+
+	wc.Stats.Log(website.Name)
+}
+
+// RefreshWebsites fetches the papers updated since the previous refresh, to update the ones already scraped
+func RefreshWebsites(websiteList []*database.Website) {
+	for _, website := range websiteList {
+		log.Infof("Refreshing %s...", website.Name)
+
+		wc := collector.GetWebsiteCollector(website, colly.AllowURLRevisit())
+
+		switch website.Name {
+		case "arXiv":
+			err := arxiv.UpdateAndLoadCategories(website)
+			if err != nil {
+				log.Errorf("loading the arXiv's categories: %s", err)
+				continue
+			}
+			arxiv.SetupCollector(wc)
+			arxiv.RefreshCategoryList(wc)
+		}
+
+		wc.Stats.Log(website.Name + " refresh")
+	}
+}
+
+func ReparseWebsites(websiteList []*database.Website) {
+	if !config.Archive.IsEnabled() {
+		log.Fatal("reparsing requires the ARCHIVE_PATH to be set")
+	}
+
+	for _, website := range websiteList {
+		log.Infof("Reparsing %s archive...", website.Name)
+
+		switch website.Name {
+		case "arXiv":
+			err := arxiv.ReparseArchive(website)
+			if err != nil {
+				log.Errorf("reparsing the arXiv's archive: %s", err)
+			}
+		}
+	}
+}
+
+// FetchWebsitesIds fetches the given ids on demand (atm: arXiv ids only)
+func FetchWebsitesIds(websiteList []*database.Website, idList []string) {
+	for _, website := range websiteList {
+		switch website.Name {
+		case "arXiv":
+			log.Infof("Fetching %d %s ids...", len(idList), website.Name)
+			report, err := arxiv.FetchIds(website, idList)
+			if err != nil {
+				log.Errorf("fetching the arXiv ids: %s", err)
+				continue
+			}
+			report.Log()
+		}
+	}
+}
+
+// FetchWebsitesQueuedIds fetches the ids queued in the fetch jobs table (atm: arXiv ids only)
+func FetchWebsitesQueuedIds(websiteList []*database.Website) {
+	for _, website := range websiteList {
+		switch website.Name {
+		case "arXiv":
+			log.Infof("Fetching the queued %s ids...", website.Name)
+			err := arxiv.FetchQueuedIds(website)
+			if err != nil {
+				log.Errorf("fetching the queued arXiv ids: %s", err)
+			}
+		}
+	}
+}
+
+func DownloadWebsitesDocuments(websiteList []*database.Website) {
+	for _, website := range websiteList {
+		log.Infof("Downloading %s documents...", website.Name)
+
+		switch website.Name {
+		case "arXiv":
+			err := arxiv.DownloadPdfs(website)
+			if err != nil {
+				log.Errorf("downloading the arXiv's PDFs: %s", err)
+			}
+		}
+	}
+}
+
+func CleanupWebsites(websiteList []*database.Website) {
+	for _, website := range websiteList {
+		s := storage.NewStorage(website.Name)
+		err := s.Init()
+		if err != nil {
+			log.Fatalf("initialising the storage of %s: %s", website.Name, err)
+		}
+
+		deletedCount, err := s.DeleteExpiredVisits()
+		if err != nil {
+			log.Errorf("cleaning up the storage of %s: %s", website.Name, err)
+			continue
+		}
+		log.Infof("deleted %d expired visited pages for %s", deletedCount, website.Name)
+	}
 }
@@ -4,46 +4,78 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/papetier/scraper/pkg/config"
 	"github.com/papetier/scraper/pkg/database"
 	log "github.com/sirupsen/logrus"
 	"net/url"
-	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
+const (
+	visitedTable = "colly_visited_pages"
+	cookiesTable = "colly_cookies"
 
-const visitedTable = "colly_storage_visited_pages"
-const cookiesTable = "colly_storage_cookies"
+	// the tables of the previous storage (without namespace), migrated on the first init
+	legacyVisitedTable = "colly_storage_visited_pages"
+	legacyCookiesTable = "colly_storage_cookies"
+)
 
-// From https://github.com/zolamk/colly-postgres-storage/blob/master/colly/postgres/storage.go
+// Originally from https://github.com/zolamk/colly-postgres-storage/blob/master/colly/postgres/storage.go
 
 // Storage implements a PostgreSQL storage backend for colly
+// Visited pages and cookies are namespaced (i.e. per website) so that several collectors can share the same tables
 type Storage struct {
 	pool         *pgxpool.Pool
+	Namespace    string
 	VisitedTable string
 	CookiesTable string
+	VisitedTTL   time.Duration
 }
 
-var DbStorage *Storage
-
 var once sync.Once
 
-func SetupSDBStorage() {
-	DbStorage = &Storage{
+func NewStorage(namespace string) *Storage {
+	return &Storage{
+		Namespace:    namespace,
 		VisitedTable: visitedTable,
 		CookiesTable: cookiesTable,
+		VisitedTTL:   config.Storage.VisitedTTL,
 	}
 }
 
 func prepareDB(s *Storage) {
 	once.Do(func() {
-		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (request_id text not null);", s.VisitedTable)
-		_, err := s.pool.Exec(context.Background(), query)
+		err := migrateLegacyTables(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			namespace text NOT NULL,
+			request_id bigint NOT NULL,
+			visited_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (namespace, request_id)
+		);`, s.VisitedTable)
+		_, err = s.pool.Exec(context.Background(), query)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		query = fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_visited_at_idx ON %s (visited_at);`, s.VisitedTable, s.VisitedTable)
+		_, err = s.pool.Exec(context.Background(), query)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		query = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (host text not null, cookies text not null);", s.CookiesTable)
+		query = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			namespace text NOT NULL,
+			host text NOT NULL,
+			cookies text NOT NULL,
+			updated_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (namespace, host)
+		);`, s.CookiesTable)
 		_, err = s.pool.Exec(context.Background(), query)
 		if err != nil {
 			log.Fatal(err)
@@ -51,13 +83,76 @@ func prepareDB(s *Storage) {
 	})
 }
 
-//Init initializes the PostgreSQL storage
+// migrateLegacyTables renames the tables of the previous storage and adds the namespace (the one of the storage, the
+// legacy entries being shared by all the collectors), the dates and the primary keys, if they weren't migrated yet
+func migrateLegacyTables(s *Storage) error {
+	namespace := "'" + strings.ReplaceAll(s.Namespace, "'", "''") + "'"
+
+	// visited pages: the uint64 request ids were stored as text (see toRequestKey for the bigint conversion)
+	err := migrateLegacyTable(s, legacyVisitedTable, s.VisitedTable, []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN namespace text NOT NULL DEFAULT %s, ADD COLUMN visited_at timestamptz NOT NULL DEFAULT now()`, s.VisitedTable, namespace),
+		fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN namespace DROP DEFAULT,
+			ALTER COLUMN request_id TYPE bigint USING (CASE WHEN request_id::numeric >= 9223372036854775808 THEN request_id::numeric - 18446744073709551616 ELSE request_id::numeric END)::bigint`, s.VisitedTable),
+		fmt.Sprintf(`DELETE FROM %s a USING %s b WHERE a.ctid < b.ctid AND a.request_id = b.request_id`, s.VisitedTable, s.VisitedTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD PRIMARY KEY (namespace, request_id)`, s.VisitedTable),
+	})
+	if err != nil {
+		return err
+	}
+
+	// cookies: a single row is kept per host
+	return migrateLegacyTable(s, legacyCookiesTable, s.CookiesTable, []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN namespace text NOT NULL DEFAULT %s, ADD COLUMN updated_at timestamptz NOT NULL DEFAULT now()`, s.CookiesTable, namespace),
+		fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN namespace DROP DEFAULT`, s.CookiesTable),
+		fmt.Sprintf(`DELETE FROM %s a USING %s b WHERE a.ctid < b.ctid AND a.host = b.host`, s.CookiesTable, s.CookiesTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD PRIMARY KEY (namespace, host)`, s.CookiesTable),
+	})
+}
+
+// migrateLegacyTable renames the legacy table (if it exists and the new one doesn't), then runs the migration queries
+func migrateLegacyTable(s *Storage, legacyTable string, table string, queryList []string) error {
+	var isLegacy bool
+	query := "SELECT to_regclass($1) IS NOT NULL AND to_regclass($2) IS NULL"
+	err := s.pool.QueryRow(context.Background(), query, legacyTable, table).Scan(&isLegacy)
+	if err != nil {
+		return fmt.Errorf("checking for the legacy storage table %s: %w", legacyTable, err)
+	}
+	if !isLegacy {
+		return nil
+	}
+	log.Infof("migrating the legacy storage table %s to %s (namespace %s)", legacyTable, table, s.Namespace)
+
+	// prepare transaction
+	tx, err := s.pool.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	queryList = append([]string{fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, legacyTable, table)}, queryList...)
+	for _, query := range queryList {
+		_, err = tx.Exec(context.Background(), query)
+		if err != nil {
+			return fmt.Errorf("migrating the legacy storage table %s: %w", legacyTable, err)
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("committing the transaction to migrate the legacy storage table %s: %w", legacyTable, err)
+	}
+
+	return nil
+}
+
+// Init initializes the PostgreSQL storage
 func (s *Storage) Init() error {
 	s.pool = database.GetPool()
 
 	err := s.pool.Ping(context.Background())
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("pinging the database: %w", err)
 	}
 
 	prepareDB(s)
@@ -67,43 +162,91 @@ func (s *Storage) Init() error {
 
 // Visited implements colly/storage.Visited()
 func (s *Storage) Visited(requestID uint64) error {
-	var err error
+	query := fmt.Sprintf(`INSERT INTO %s (namespace, request_id, visited_at) VALUES ($1, $2, now())
+		ON CONFLICT (namespace, request_id) DO UPDATE SET visited_at = EXCLUDED.visited_at;`, s.VisitedTable)
 
-	query := fmt.Sprintf(`INSERT INTO %s (request_id) VALUES($1);`, s.VisitedTable)
-
-	_, err = s.pool.Exec(context.Background(), query, strconv.FormatUint(requestID, 10))
+	_, err := s.pool.Exec(context.Background(), query, s.Namespace, toRequestKey(requestID))
+	if err != nil {
+		return fmt.Errorf("saving visited request %d: %w", requestID, err)
+	}
 
-	return err
+	return nil
 }
 
 // IsVisited implements colly/storage.IsVisited()
+// An entry older than the visited TTL (if any) is considered as not visited
 func (s *Storage) IsVisited(requestID uint64) (bool, error) {
-
 	var isVisited bool
 
-	query := fmt.Sprintf(`SELECT EXISTS(SELECT request_id FROM %s WHERE request_id = $1)`, s.VisitedTable)
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE namespace = $1 AND request_id = $2 AND visited_at > $3)`, s.VisitedTable)
 
-	err := s.pool.QueryRow(context.Background(), query, strconv.FormatUint(requestID, 10)).Scan(&isVisited)
+	err := s.pool.QueryRow(context.Background(), query, s.Namespace, toRequestKey(requestID), s.visitedCutoff()).Scan(&isVisited)
+	if err != nil {
+		return false, fmt.Errorf("checking if request %d was visited: %w", requestID, err)
+	}
 
-	return isVisited, err
+	return isVisited, nil
 }
 
 // Cookies implements colly/storage.Cookies()
 func (s *Storage) Cookies(u *url.URL) string {
-
 	var cookies string
 
-	query := fmt.Sprintf(`SELECT cookies FROM %s WHERE host = $1;`, s.CookiesTable)
+	query := fmt.Sprintf(`SELECT cookies FROM %s WHERE namespace = $1 AND host = $2;`, s.CookiesTable)
 
-	s.pool.QueryRow(context.Background(), query, u.Host).Scan(&cookies)
+	rows, err := s.pool.Query(context.Background(), query, s.Namespace, u.Host)
+	defer rows.Close()
+	if err != nil {
+		log.Errorf("fetching the cookies for %s: %s", u.Host, err)
+		return ""
+	}
+	for rows.Next() {
+		err = rows.Scan(&cookies)
+		if err != nil {
+			log.Errorf("scanning the cookies for %s: %s", u.Host, err)
+			return ""
+		}
+	}
 
 	return cookies
 }
 
 // SetCookies implements colly/storage.SetCookies()
 func (s *Storage) SetCookies(u *url.URL, cookies string) {
+	query := fmt.Sprintf(`INSERT INTO %s (namespace, host, cookies, updated_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (namespace, host) DO UPDATE SET cookies = EXCLUDED.cookies, updated_at = EXCLUDED.updated_at;`, s.CookiesTable)
+
+	_, err := s.pool.Exec(context.Background(), query, s.Namespace, u.Host, cookies)
+	if err != nil {
+		log.Errorf("saving the cookies for %s: %s", u.Host, err)
+	}
+}
+
+// DeleteExpiredVisits removes the visited entries older than the visited TTL and returns the deleted count
+func (s *Storage) DeleteExpiredVisits() (int64, error) {
+	if s.VisitedTTL <= 0 {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1 AND visited_at <= $2`, s.VisitedTable)
 
-	query := fmt.Sprintf(`INSERT INTO %s (host, cookies) VALUES($1, $2);`, s.CookiesTable)
+	result, err := s.pool.Exec(context.Background(), query, s.Namespace, s.visitedCutoff())
+	if err != nil {
+		return 0, fmt.Errorf("deleting the expired visited entries of %s: %w", s.Namespace, err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// visitedCutoff returns the date before which a visited entry is expired (zero time if no TTL is set)
+func (s *Storage) visitedCutoff() time.Time {
+	if s.VisitedTTL <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-s.VisitedTTL)
+}
 
-	s.pool.Exec(context.Background(), query, u.Host, cookies)
+// toRequestKey stores colly's uint64 request ids in a bigint column (bit-preserving conversion)
+func toRequestKey(requestID uint64) int64 {
+	return int64(requestID)
 }